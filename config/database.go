@@ -29,8 +29,30 @@ func ConnectDB() {
 		log.Fatalf("Gagal terhubung ke database: %v", err)
 	}
 
+	if err := Migrate(db); err != nil {
+		log.Fatalf("Gagal melakukan migrasi database: %v", err)
+	}
+
+	DB = db
+	fmt.Println("Sukses terhubung ke database dan migrasi berhasil")
+}
+
+// Migrate membuat dan memperbarui tabel sesuai model. Dipisah dari ConnectDB agar bisa dipakai
+// juga oleh test integrasi.
+func Migrate(db *gorm.DB) error {
+	if err := dedupeInvoiceCodes(db); err != nil {
+		return fmt.Errorf("gagal memperbaiki kode invoice ganda: %w", err)
+	}
+	if err := renumberLogProdukVersions(db); err != nil {
+		return fmt.Errorf("gagal memperbaiki versi log produk: %w", err)
+	}
+
+	// Transaksi yang dibuat sebelum kolom status ada perlu diisi statusnya setelah migrasi
+	backfillStatus := db.Migrator().HasTable(&models.Transaction{}) &&
+		!db.Migrator().HasColumn(&models.Transaction{}, "Status")
+
 	// Automigrate tabel berdasarkan model yang ada
-	err = db.AutoMigrate(
+	err := db.AutoMigrate(
 		&models.User{},
 		&models.Toko{},
 		&models.Produk{},
@@ -38,13 +60,100 @@ func ConnectDB() {
 		&models.Category{},
 		&models.LogProduk{},
 		&models.Transaction{},
+		&models.SubTransaction{},
 		&models.DetailTransaction{},
 		&models.Alamat{},
+		&models.TransactionStatusHistory{},
+		&models.IdempotencyKey{},
+		&models.InvoiceSequence{},
+		&models.Payment{},
+		&models.PaymentNotification{},
+		&models.Refund{},
+		&models.CartItem{},
+		&models.Voucher{},
+		&models.VoucherUsage{},
+		&models.TrackingEvent{},
+		&models.ProdukOpsi{},
+		&models.ProdukOpsiNilai{},
+		&models.ProdukVarian{},
+		&models.InventoryMovement{},
+		&models.Notifikasi{},
 	)
 	if err != nil {
-		log.Fatalf("Gagal melakukan migrasi database: %v", err)
+		return err
 	}
 
-	DB = db
-	fmt.Println("Sukses terhubung ke database dan migrasi berhasil")
+	if backfillStatus {
+		if err := backfillTransactionStatus(db); err != nil {
+			return fmt.Errorf("gagal mengisi status transaksi lama: %w", err)
+		}
+	}
+
+	// Index keranjang lama (user, produk) diganti index yang menyertakan varian
+	if db.Migrator().HasIndex(&models.CartItem{}, "idx_cart_user_produk") {
+		if err := db.Migrator().DropIndex(&models.CartItem{}, "idx_cart_user_produk"); err != nil {
+			return fmt.Errorf("gagal menghapus index keranjang lama: %w", err)
+		}
+	}
+	return nil
+}
+
+// backfillTransactionStatus menandai transaksi yang dibuat sebelum siklus status ada sebagai
+// selesai. Tanpa ini kolom baru terisi default pending_payment sehingga scheduler kadaluarsa
+// akan mengkadaluarsakan pesanan lama dan mengembalikan stoknya.
+func backfillTransactionStatus(db *gorm.DB) error {
+	return db.Model(&models.Transaction{}).Where("1 = 1").
+		Update("status", models.TrxStatusCompleted).Error
+}
+
+// dedupeInvoiceCodes menomori ulang kode invoice ganda dari format lama (INV-<unix>) sebelum
+// unique index kode_invoice dibuat. Transaksi tertua mempertahankan kodenya, sisanya diberi
+// akhiran ID transaksi.
+func dedupeInvoiceCodes(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Transaction{}) ||
+		db.Migrator().HasIndex(&models.Transaction{}, "idx_transactions_kode_invoice") {
+		return nil
+	}
+	return db.Exec(`UPDATE transactions t
+		JOIN (SELECT kode_invoice, MIN(id) AS id_pertama FROM transactions
+			GROUP BY kode_invoice HAVING COUNT(*) > 1) d ON d.kode_invoice = t.kode_invoice
+		SET t.kode_invoice = CONCAT(t.kode_invoice, '-', t.id)
+		WHERE t.id <> d.id_pertama`).Error
+}
+
+// renumberLogProdukVersions menomori ulang versi LogProduk per produk sebelum index
+// (id_produk, versi) dijadikan unique. Versi lama dihitung per varian sehingga bisa ganda dalam
+// satu produk; urutan snapshot tetap dipertahankan berdasarkan ID. Index lama yang belum unique
+// dihapus agar dibuat ulang oleh AutoMigrate.
+func renumberLogProdukVersions(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.LogProduk{}) {
+		return nil
+	}
+	indexes, err := db.Migrator().GetIndexes(&models.LogProduk{})
+	if err != nil {
+		return err
+	}
+
+	var lama gorm.Index
+	for _, index := range indexes {
+		if index.Name() == "idx_log_produk_versi" {
+			lama = index
+		}
+	}
+	if lama != nil {
+		if unique, _ := lama.Unique(); unique {
+			return nil
+		}
+	}
+
+	if err := db.Exec(`UPDATE log_produks l
+		JOIN (SELECT id, ROW_NUMBER() OVER (PARTITION BY id_produk ORDER BY id) AS versi_baru
+			FROM log_produks) v ON v.id = l.id
+		SET l.versi = v.versi_baru`).Error; err != nil {
+		return err
+	}
+	if lama != nil {
+		return db.Migrator().DropIndex(&models.LogProduk{}, "idx_log_produk_versi")
+	}
+	return nil
 }
@@ -203,9 +203,21 @@ func UpdateAlamatByID(c *fiber.Ctx) error {
 		})
 	}
 
-	err = services.UpdateAlamatByID(uint(alamatID), userID, map[string]interface{}{
+	updates := map[string]interface{}{
 		"judul_alamat": alamatRequest.JudulAlamat,
-	})
+	}
+	// Lokasi hanya diperbarui jika dikirim, dipakai untuk menghitung ongkos kirim
+	if alamatRequest.IDProvinsi != "" {
+		updates["id_provinsi"] = alamatRequest.IDProvinsi
+	}
+	if alamatRequest.IDKota != "" {
+		updates["id_kota"] = alamatRequest.IDKota
+	}
+	if alamatRequest.KodePos != "" {
+		updates["kode_pos"] = alamatRequest.KodePos
+	}
+
+	err = services.UpdateAlamatByID(uint(alamatID), userID, updates)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
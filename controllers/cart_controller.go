@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// cartErrorCode memetakan error dari service keranjang ke HTTP status
+func cartErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrItemKeranjangTidakDitemukan), errors.Is(err, services.ErrProdukTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrKuantitasTidakValid), errors.Is(err, services.ErrStokTidakMencukupi),
+		errors.Is(err, services.ErrKeranjangKosong):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrKeranjangBerubah):
+		return fiber.StatusConflict
+	default:
+		return checkoutErrorCode(err)
+	}
+}
+
+// Get Cart
+// @Summary Get Cart
+// @Description Get the current user's cart grouped by store, validated against current product prices and stock.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 500 {object} Response
+// @Router /cart [get]
+func GetCart(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	cart, err := services.GetCart(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil keranjang",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil keranjang",
+		"errors":  nil,
+		"data":    cart,
+	})
+}
+
+// Add Cart Item
+// @Summary Add Cart Item
+// @Description Add a product to the cart. Products with variants require id_varian. Adding a product or variant already in the cart increases its quantity.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body object{product_id=int,id_varian=int,kuantitas=int,harga_jual=int} true "Cart item"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Router /cart/items [post]
+func AddCartItem(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var req services.CheckoutItem
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	item, err := services.AddCartItem(userID, req.ProductID, req.IDVarian, req.Kuantitas, req.HargaJual)
+	if err != nil {
+		return c.Status(cartErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menambahkan item ke keranjang",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Item berhasil ditambahkan ke keranjang",
+		"errors":  nil,
+		"data":    item,
+	})
+}
+
+// Update Cart Item
+// @Summary Update Cart Item
+// @Description Change the quantity of a cart item. harga_jual is only updated when provided.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Cart item ID"
+// @Param request body object{kuantitas=int,harga_jual=int} true "Cart item"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Router /cart/items/{id} [put]
+func UpdateCartItem(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	itemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID item keranjang tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Kuantitas int  `json:"kuantitas"`
+		HargaJual *int `json:"harga_jual"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	item, err := services.UpdateCartItem(userID, uint(itemID), req.Kuantitas, req.HargaJual)
+	if err != nil {
+		return c.Status(cartErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal memperbarui item keranjang",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Item keranjang berhasil diperbarui",
+		"errors":  nil,
+		"data":    item,
+	})
+}
+
+// Remove Cart Item
+// @Summary Remove Cart Item
+// @Description Remove an item from the cart.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Cart item ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Router /cart/items/{id} [delete]
+func RemoveCartItem(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	itemID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID item keranjang tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	if err := services.RemoveCartItem(userID, uint(itemID)); err != nil {
+		return c.Status(cartErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menghapus item keranjang",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Item keranjang berhasil dihapus",
+		"errors":  nil,
+		"data":    nil,
+	})
+}
+
+// Checkout Cart
+// @Summary Checkout Cart
+// @Description Convert the current user's cart into a transaction. Stock and prices are validated again at checkout and the purchased items are removed from the cart.
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.CartCheckoutRequest true "Checkout data"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Success 201 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Failure 422 {object} Response
+// @Router /cart/checkout [post]
+func CheckoutCart(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var req services.CartCheckoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trx, err := services.CheckoutCart(userID, req)
+	if err != nil {
+		message := checkoutErrorMessage(err)
+		switch {
+		case errors.Is(err, services.ErrKeranjangKosong):
+			message = "Keranjang belanja kosong"
+		case errors.Is(err, services.ErrKeranjangBerubah):
+			message = "Keranjang berubah selama checkout, silakan coba lagi"
+		}
+		return c.Status(cartErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": message,
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  true,
+		"message": "Transaksi berhasil dibuat",
+		"errors":  nil,
+		"data":    formatCreatedTransaction(trx),
+	})
+}
@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// inventoryErrorCode memetakan error dari service inventaris ke HTTP status
+func inventoryErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProdukTidakDitemukan), errors.Is(err, services.ErrVarianTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrAksesProdukDitolak):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrAlasanPenyesuaianKosong), errors.Is(err, services.ErrPenyesuaianNol),
+		errors.Is(err, services.ErrVarianWajibDipilih):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrStokTidakMencukupi):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Adjust Product Stock
+// @Summary Adjust Product Stock
+// @Description Add or remove stock of the current user's product, for example after a stock count or for damaged goods. Positive kuantitas adds stock, negative kuantitas removes it. id_varian is required for products with variants. The adjustment is recorded in the inventory ledger.
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body services.StockAdjustmentInput true "Stock adjustment"
+// @Success 201 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /product/{id}/stock-adjustments [post]
+func AdjustProductStock(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produkID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID produk tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var input services.StockAdjustmentInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	movement, err := services.AdjustStock(userID, uint(produkID), input)
+	if err != nil {
+		return c.Status(inventoryErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menyesuaikan stok produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil menyesuaikan stok produk",
+		"errors":  nil,
+		"data":    movement,
+	})
+}
+
+// Get Product Stock Movements
+// @Summary Get Product Stock Movements
+// @Description Get the inventory ledger of the current user's product, newest first. Admins can view any product.
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param id_varian query int false "Filter by variant ID"
+// @Param tipe query string false "Filter by movement type (sale, cancel, refund, adjustment, import)"
+// @Param limit query int false "Limit per page" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /product/{id}/stock-movements [get]
+func GetProductStockMovements(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produkID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID produk tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	varianID, _ := strconv.Atoi(c.Query("id_varian"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	filter := services.StockMovementFilter{
+		Tipe:  c.Query("tipe"),
+		Page:  page,
+		Limit: limit,
+	}
+	if varianID > 0 {
+		filter.IDVarian = uint(varianID)
+	}
+
+	movements, total, totalPages, err := services.GetStockMovements(userID, uint(produkID), filter)
+	if err != nil {
+		return c.Status(inventoryErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil riwayat stok",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil riwayat stok",
+		"errors":  nil,
+		"data":    movements,
+		"pagination": fiber.Map{
+			"page":       page,
+			"limit":      limit,
+			"total_data": total,
+			"total_page": totalPages,
+		},
+	})
+}
+
+// Reconcile Inventory
+// @Summary Reconcile Inventory
+// @Description Recompute stock from the inventory ledger and report products or variants whose stock differs from the ledger. Sellers check their own store, admins check all stores.
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /inventory/reconciliation [get]
+func ReconcileInventory(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	result, err := services.ReconcileInventory(userID)
+	if err != nil {
+		return c.Status(inventoryErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mencocokkan stok",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mencocokkan stok",
+		"errors":  nil,
+		"data":    result,
+	})
+}
+
+// Get Low Stock Items
+// @Summary Get Low Stock Items
+// @Description Get products without variants and variants of the current user's store whose stock is at or below the product's low-stock threshold. Admins see all stores.
+// @Tags Inventory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /inventory/low-stock [get]
+func GetLowStockItems(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	items, err := services.GetLowStockItems(userID)
+	if err != nil {
+		return c.Status(inventoryErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil produk dengan stok menipis",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil produk dengan stok menipis",
+		"errors":  nil,
+		"data":    items,
+	})
+}
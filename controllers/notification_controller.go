@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// Get Notifications
+// @Summary Get Notifications
+// @Description Get in-app notifications of the current user, newest first, such as low-stock alerts and daily stock digests.
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param unread query bool false "Only unread notifications"
+// @Param limit query int false "Limit per page" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 500 {object} Response
+// @Router /notifications [get]
+func GetNotifications(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	unreadOnly, _ := strconv.ParseBool(c.Query("unread"))
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	notifications, total, totalPages, err := services.GetNotifications(userID, unreadOnly, page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil notifikasi",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":       true,
+		"message":      "Berhasil mengambil notifikasi",
+		"errors":       nil,
+		"data":         notifications,
+		"belum_dibaca": services.CountUnreadNotifications(userID),
+		"pagination": fiber.Map{
+			"page":       page,
+			"limit":      limit,
+			"total_data": total,
+			"total_page": totalPages,
+		},
+	})
+}
+
+// Mark Notification Read
+// @Summary Mark Notification Read
+// @Description Mark one notification of the current user as read.
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Router /notifications/{id}/read [put]
+func MarkNotificationRead(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	notifID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID notifikasi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	notif, err := services.MarkNotificationRead(userID, uint(notifID))
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		if errors.Is(err, services.ErrNotifikasiTidakDitemukan) {
+			code = fiber.StatusNotFound
+		}
+		return c.Status(code).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menandai notifikasi",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Notifikasi ditandai sudah dibaca",
+		"errors":  nil,
+		"data":    notif,
+	})
+}
+
+// Mark All Notifications Read
+// @Summary Mark All Notifications Read
+// @Description Mark every unread notification of the current user as read.
+// @Tags Notification
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 500 {object} Response
+// @Router /notifications/read-all [put]
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	updated, err := services.MarkAllNotificationsRead(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menandai notifikasi",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Semua notifikasi ditandai sudah dibaca",
+		"errors":  nil,
+		"data":    fiber.Map{"jumlah": updated},
+	})
+}
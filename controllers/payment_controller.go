@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// paymentErrorCode memetakan error dari service pembayaran ke HTTP status
+func paymentErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTransaksiTidakDitemukan), errors.Is(err, services.ErrPembayaranTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrTransaksiTidakMenungguPembayaran):
+		return fiber.StatusConflict
+	case errors.Is(err, services.ErrProviderPembayaranTidakDikenal), errors.Is(err, services.ErrPayloadNotifikasiTidakValid):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrSignatureTidakValid):
+		return fiber.StatusUnauthorized
+	case errors.Is(err, services.ErrJumlahPembayaranTidakSesuai):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Create Payment
+// @Summary Create Payment
+// @Description Create a payment charge for a pending transaction using the provider from method_bayar. An unexpired pending charge is returned instead of creating a new one.
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /trx/{id}/payment [post]
+func CreatePayment(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trxID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	payment, err := services.CreatePayment(uint(trxID), userID)
+	if err != nil {
+		return c.Status(paymentErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal membuat pembayaran",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Tagihan pembayaran berhasil dibuat",
+		"errors":  nil,
+		"data":    payment,
+	})
+}
+
+// Get Payment
+// @Summary Get Payment
+// @Description Get the latest payment of a transaction. The status is refreshed from the provider before it is returned.
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Router /trx/{id}/payment [get]
+func GetPayment(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trxID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	payment, err := services.GetLatestPayment(uint(trxID), userID)
+	if err != nil {
+		return c.Status(paymentErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil pembayaran",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil pembayaran",
+		"errors":  nil,
+		"data":    payment,
+	})
+}
+
+// Settle Mock Payment
+// @Summary Settle Mock Payment
+// @Description Simulate a successful payment on the mock provider. Only available when PAYMENT_MOCK_ENABLED=true.
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param referensi path string true "Payment reference"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /payments/mock/{referensi}/settle [post]
+func SettleMockPayment(c *fiber.Ctx) error {
+	payment, err := services.SettleMockPayment(c.Params("referensi"))
+	if err != nil {
+		return c.Status(paymentErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal memproses pembayaran mock",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Pembayaran mock berhasil",
+		"errors":  nil,
+		"data":    payment,
+	})
+}
+
+// Payment Webhook
+// @Summary Payment Webhook
+// @Description Receive a payment notification from a provider. The raw body must be signed with HMAC-SHA256 using the provider secret and the hex digest sent in the X-Signature header. Repeated notifications are acknowledged without being applied twice.
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param provider path string true "Payment provider"
+// @Param X-Signature header string true "HMAC-SHA256 signature of the raw body"
+// @Param request body services.PaymentNotificationPayload true "Notification payload"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Failure 422 {object} Response
+// @Router /payments/webhook/{provider} [post]
+func PaymentWebhook(c *fiber.Ctx) error {
+	notif, err := services.HandlePaymentWebhook(c.Params("provider"), c.Body(), c.Get("X-Signature"))
+	if err != nil {
+		return c.Status(paymentErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal memproses notifikasi pembayaran",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Notifikasi pembayaran diterima",
+		"errors":  nil,
+		"data": fiber.Map{
+			"id":            notif.ID,
+			"id_notifikasi": notif.IDNotifikasi,
+			"status":        notif.Status,
+		},
+	})
+}
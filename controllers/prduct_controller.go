@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"encoding/json"
 	"errors"
 	"strconv"
 	"time"
@@ -12,6 +13,7 @@ import (
 	"github.com/habbazettt/evermos-service-go/services"
 	"github.com/habbazettt/evermos-service-go/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // Get All Products
@@ -81,7 +83,7 @@ func GetAllProducts(c *fiber.Ctx) error {
 
 // Get Product by ID
 // @Summary Get Product by ID
-// @Description Get a product by its ID.
+// @Description Get a product by its ID. Archived products are only returned to admins, the store owner and buyers who ordered them.
 // @Tags Product
 // @Accept json
 // @Produce json
@@ -106,13 +108,22 @@ func GetProductByID(c *fiber.Ctx) error {
 
 	// Ambil produk berdasarkan ID
 	var produk models.Produk
-	err = config.DB.Preload("FotoProduk").First(&produk, produkID).Error
+	err = config.DB.Preload("FotoProduk").
+		Preload("Opsi", func(db *gorm.DB) *gorm.DB { return db.Order("urutan ASC") }).
+		Preload("Opsi.Nilai", func(db *gorm.DB) *gorm.DB { return db.Order("urutan ASC") }).
+		Preload("Varian.Nilai").
+		First(&produk, produkID).Error
 
-	// Jika produk tidak ditemukan
+	// Produk yang diarsipkan tetap bisa dibuka dari riwayat pesanan
 	if errors.Is(err, gorm.ErrRecordNotFound) {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"message": "Produk tidak ditemukan",
-		})
+		userID, _ := middleware.ExtractUserID(c)
+		archived, archivedErr := services.FindArchivedProductForUser(userID, uint(produkID))
+		if archivedErr != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"message": "Produk tidak ditemukan",
+			})
+		}
+		produk, err = *archived, nil
 	}
 
 	// Jika ada error lain saat query
@@ -139,10 +150,14 @@ func GetProductByID(c *fiber.Ctx) error {
 // @Param nama_produk formData string true "Product name"
 // @Param deskripsi formData string true "Product description"
 // @Param id_category formData int true "Category ID"
-// @Param harga_reseller formData int true "Reseller price"
+// @Param harga_reseller formData int true "Reseller price; 0 means resellers pay the consumer price"
 // @Param harga_konsumen formData int true "Consumer price"
 // @Param stok formData int true "Product stock"
+// @Param stok_minimum formData int false "Low-stock alert threshold; the seller is notified when stock drops to this value (default 0, alert when sold out)"
+// @Param berat formData int false "Product weight in grams, used for shipping cost (default 1000)"
+// @Param varian formData string false "Optional variants as JSON, same format as PUT /product/{id}/variants"
 // @Param photos formData file true "Product photos (multiple files allowed)"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
 // @Success 201 {object} Response
 // @Failure 400 {object} Response
 // @Failure 401 {object} Response
@@ -155,8 +170,8 @@ func CreateProduct(c *fiber.Ctx) error {
 	}
 
 	// Cek apakah user memiliki toko
-	var toko models.Toko
-	if err := config.DB.Where("id_user = ?", userID).First(&toko).Error; err != nil {
+	actor, err := services.ResolveActor(userID)
+	if err != nil || actor.TokoID == 0 {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Hanya pemilik toko yang bisa menambah produk"})
 	}
 
@@ -167,6 +182,14 @@ func CreateProduct(c *fiber.Ctx) error {
 	hargaReseller, _ := strconv.Atoi(c.FormValue("harga_reseller"))
 	hargaKonsumen, _ := strconv.Atoi(c.FormValue("harga_konsumen"))
 	stok, _ := strconv.Atoi(c.FormValue("stok"))
+	stokMinimum, _ := strconv.Atoi(c.FormValue("stok_minimum"))
+	if stokMinimum < 0 {
+		stokMinimum = 0
+	}
+	berat, _ := strconv.Atoi(c.FormValue("berat"))
+	if berat <= 0 {
+		berat = services.BeratDefaultGram
+	}
 
 	slug := utils.GenerateSlug(namaProduk)
 
@@ -179,8 +202,10 @@ func CreateProduct(c *fiber.Ctx) error {
 		HargaReseller: hargaReseller,
 		HargaKonsumen: hargaKonsumen,
 		Stok:          stok,
+		StokMinimum:   stokMinimum,
+		Berat:         berat,
 		Deskripsi:     deskripsi,
-		IDToko:        toko.ID,
+		IDToko:        actor.TokoID,
 		IDCategory:    uint(idCategory),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
@@ -191,22 +216,33 @@ func CreateProduct(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menyimpan produk"})
 	}
 
-	// Tambahkan ke LogProduk
-	logProduct := models.LogProduk{
-		IDProduk:      product.ID,
-		NamaProduk:    product.NamaProduk,
-		Slug:          product.Slug,
-		HargaReseller: product.HargaReseller,
-		HargaKonsumen: product.HargaKonsumen,
-		Deskripsi:     product.Deskripsi,
-		IDToko:        product.IDToko,
-		IDCategory:    product.IDCategory,
-	}
-	if err := tx.Create(&logProduct).Error; err != nil {
+	// Tambahkan versi pertama ke LogProduk
+	if _, err := services.SnapshotProduct(tx, &product, nil); err != nil {
 		tx.Rollback()
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menyimpan ke log produk"})
 	}
 
+	// Varian bersifat opsional; stok produk bervarian dihitung dari stok variannya
+	stockChange := services.SellerStockChange(userID, "Stok awal produk")
+	if raw := c.FormValue("varian"); raw != "" {
+		var variants services.ProductVariantsInput
+		if err := json.Unmarshal([]byte(raw), &variants); err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Data varian tidak valid", "error": err.Error()})
+		}
+		if err := services.SetProductVariants(tx, &product, variants, stockChange); err != nil {
+			tx.Rollback()
+			return c.Status(variantErrorCode(err)).JSON(fiber.Map{"message": "Gagal menyimpan varian produk", "error": err.Error()})
+		}
+		if err := tx.Select("stok").First(&product, product.ID).Error; err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menyimpan varian produk"})
+		}
+	} else if err := services.RecordStockMovement(tx, product.ID, nil, product.Stok, stockChange); err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal mencatat stok awal produk"})
+	}
+
 	// Upload foto produk ke Cloudinary
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -258,7 +294,7 @@ func CreateProduct(c *fiber.Ctx) error {
 
 // Update Product
 // @Summary Update Product
-// @Description Update a product's information.
+// @Description Update a product's information. Changing the price, name, description or category records a new product snapshot version; existing orders keep the snapshot they were bought with.
 // @Tags Product
 // @Accept multipart/form-data
 // @Produce json
@@ -266,6 +302,12 @@ func CreateProduct(c *fiber.Ctx) error {
 // @Param id path int true "Product ID"
 // @Param nama_produk formData string false "Product name"
 // @Param deskripsi formData string false "Product description"
+// @Param id_category formData int false "Category ID"
+// @Param harga_reseller formData int false "Reseller price; 0 means resellers pay the consumer price"
+// @Param harga_konsumen formData int false "Consumer price"
+// @Param stok formData int false "Product stock"
+// @Param stok_minimum formData int false "Low-stock alert threshold"
+// @Param berat formData int false "Product weight in grams"
 // @Param photos formData file false "Product photos (multiple files allowed)"
 // @Success 200 {object} Response
 // @Failure 400 {object} Response
@@ -285,18 +327,21 @@ func UpdateProduct(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID produk tidak valid"})
 	}
 
+	tx := config.DB.Begin()
+
+	// Produk dikunci agar edit paralel tidak saling menimpa dan nomor versi LogProduk tidak bentrok
 	var produk models.Produk
-	if err := config.DB.Preload("FotoProduk").First(&produk, produkID).Error; err != nil {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("FotoProduk").First(&produk, produkID).Error; err != nil {
+		tx.Rollback()
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Produk tidak ditemukan"})
 	}
 
-	var toko models.Toko
-	if err := config.DB.Where("id = ?", produk.IDToko).First(&toko).Error; err != nil || toko.IDUser != userID {
+	actor, err := services.ResolveActor(userID)
+	if err != nil || !actor.CanManageProduct(&produk) {
+		tx.Rollback()
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Anda tidak memiliki izin untuk mengubah produk ini"})
 	}
 
-	tx := config.DB.Begin()
-
 	form, _ := c.MultipartForm()
 
 	if values, ok := form.Value["nama_produk"]; ok && len(values) > 0 {
@@ -306,13 +351,74 @@ func UpdateProduct(c *fiber.Ctx) error {
 	if values, ok := form.Value["deskripsi"]; ok && len(values) > 0 {
 		produk.Deskripsi = values[0]
 	}
+	if values, ok := form.Value["id_category"]; ok && len(values) > 0 {
+		idCategory, err := strconv.Atoi(values[0])
+		if err != nil || idCategory <= 0 {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Kategori tidak valid"})
+		}
+		produk.IDCategory = uint(idCategory)
+	}
+	if values, ok := form.Value["harga_reseller"]; ok && len(values) > 0 {
+		harga, err := strconv.Atoi(values[0])
+		if err != nil || harga < 0 {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Harga reseller tidak valid"})
+		}
+		produk.HargaReseller = harga
+	}
+	if values, ok := form.Value["harga_konsumen"]; ok && len(values) > 0 {
+		harga, err := strconv.Atoi(values[0])
+		if err != nil || harga <= 0 {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Harga konsumen tidak valid"})
+		}
+		produk.HargaKonsumen = harga
+	}
+	if values, ok := form.Value["berat"]; ok && len(values) > 0 {
+		berat, err := strconv.Atoi(values[0])
+		if err != nil || berat <= 0 {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Berat produk tidak valid"})
+		}
+		produk.Berat = berat
+	}
+	if values, ok := form.Value["stok_minimum"]; ok && len(values) > 0 {
+		stokMinimum, err := strconv.Atoi(values[0])
+		if err != nil || stokMinimum < 0 {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Stok minimum tidak valid"})
+		}
+		produk.StokMinimum = stokMinimum
+	}
 	produk.UpdatedAt = time.Now()
 
-	if err := tx.Save(&produk).Error; err != nil {
+	if err := tx.Omit("stok").Save(&produk).Error; err != nil {
 		tx.Rollback()
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal memperbarui produk"})
 	}
 
+	// Perubahan harga atau data deskriptif menghasilkan versi LogProduk baru
+	if _, err := services.SnapshotProduct(tx, &produk, nil); err != nil {
+		tx.Rollback()
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menyimpan ke log produk"})
+	}
+
+	// Perubahan stok melalui inventory service
+	if values, ok := form.Value["stok"]; ok && len(values) > 0 {
+		stok, err := strconv.Atoi(values[0])
+		if err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Stok tidak valid"})
+		}
+		if err := services.SetStock(tx, produk.ID, stok,
+			services.SellerStockChange(userID, "Stok diubah melalui edit produk")); err != nil {
+			tx.Rollback()
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Gagal memperbarui stok produk", "error": err.Error()})
+		}
+		produk.Stok = stok
+	}
+
 	files := form.File["photos"]
 	var fotoProdukList []models.FotoProduk
 
@@ -355,7 +461,7 @@ func UpdateProduct(c *fiber.Ctx) error {
 
 // Delete Product
 // @Summary Delete Product
-// @Description Delete a product by its ID.
+// @Description Archive a product by its ID (soft delete). The product is hidden from the catalogue and cannot be bought, but stays available in order history and can be restored with the unarchive endpoint until it is purged.
 // @Tags Product
 // @Accept json
 // @Produce json
@@ -364,7 +470,9 @@ func UpdateProduct(c *fiber.Ctx) error {
 // @Success 200 {object} Response
 // @Failure 400 {object} Response
 // @Failure 401 {object} Response
+// @Failure 403 {object} Response
 // @Failure 404 {object} Response
+// @Failure 409 {object} Response
 // @Failure 500 {object} Response
 // @Router /product/{id} [delete]
 func DeleteProduct(c *fiber.Ctx) error {
@@ -379,36 +487,13 @@ func DeleteProduct(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID produk tidak valid"})
 	}
 
-	var produk models.Produk
-	if err := config.DB.Preload("FotoProduk").First(&produk, produkID).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Produk tidak ditemukan"})
-	}
-
-	var toko models.Toko
-	if err := config.DB.Where("id = ?", produk.IDToko).First(&toko).Error; err != nil || toko.IDUser != userID {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"message": "Anda tidak memiliki izin untuk menghapus produk ini"})
-	}
-
-	tx := config.DB.Begin()
-
-	for _, foto := range produk.FotoProduk {
-		services.DeleteFromCloudinary(foto.URL)
-	}
-
-	if err := tx.Where("id_produk = ?", produk.ID).Delete(&models.FotoProduk{}).Error; err != nil {
-		tx.Rollback()
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menghapus foto produk dari database"})
-	}
-
-	if err := tx.Delete(&produk).Error; err != nil {
-		tx.Rollback()
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menghapus produk"})
+	produk, err := services.ArchiveProduct(userID, uint(produkID))
+	if err != nil {
+		return c.Status(productArchiveErrorCode(err)).JSON(fiber.Map{"message": err.Error()})
 	}
 
-	tx.Commit()
-
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Produk berhasil dihapus",
+		"message": "Produk berhasil diarsipkan",
 		"produk":  produk,
 	})
 }
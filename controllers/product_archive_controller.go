@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// productArchiveErrorCode memetakan error arsip produk ke HTTP status
+func productArchiveErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProdukTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrAksesProdukDitolak):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrProdukSudahDiarsipkan), errors.Is(err, services.ErrProdukTidakDiarsipkan):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Archive Product
+// @Summary Archive Product
+// @Description Archive one of the current user's products. The product is hidden from the catalogue and cannot be added to carts or bought; photos, variants and stock are kept.
+// @Tags Product
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /product/{id}/archive [put]
+func ArchiveProduct(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produkID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID produk tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produk, err := services.ArchiveProduct(userID, uint(produkID))
+	if err != nil {
+		return c.Status(productArchiveErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengarsipkan produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Produk berhasil diarsipkan",
+		"errors":  nil,
+		"data":    produk,
+	})
+}
+
+// Unarchive Product
+// @Summary Unarchive Product
+// @Description Restore one of the current user's archived products to the catalogue.
+// @Tags Product
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /product/{id}/unarchive [put]
+func UnarchiveProduct(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produkID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID produk tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produk, err := services.UnarchiveProduct(userID, uint(produkID))
+	if err != nil {
+		return c.Status(productArchiveErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal memulihkan produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Produk berhasil dipulihkan",
+		"errors":  nil,
+		"data":    produk,
+	})
+}
+
+// Get Archived Products
+// @Summary Get Archived Products
+// @Description Get the current user's archived products, most recently archived first.
+// @Tags Product
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Limit per page" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /product/archived [get]
+func GetArchivedProducts(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	produkList, total, totalPages, err := services.GetArchivedProducts(userID, page, limit)
+	if err != nil {
+		return c.Status(productArchiveErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil produk yang diarsipkan",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil produk yang diarsipkan",
+		"errors":  nil,
+		"data":    produkList,
+		"pagination": fiber.Map{
+			"page":       page,
+			"limit":      limit,
+			"total_data": total,
+			"total_page": totalPages,
+		},
+	})
+}
+
+// Purge Archived Products
+// @Summary Purge Archived Products
+// @Description Permanently delete products archived longer than the retention period, together with their photos, variants and cart items. Order history is kept through the product snapshots. Admin only.
+// @Tags Product
+// @Produce json
+// @Security BearerAuth
+// @Param retention_days query int false "Minimum days archived (defaults to PRODUCT_ARCHIVE_RETENTION, 90 days)"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 500 {object} Response
+// @Router /product/purge [post]
+func PurgeArchivedProducts(c *fiber.Ctx) error {
+	cfg := services.LoadProductPurgeConfig()
+	if v := c.Query("retention_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "retention_days harus berupa angka tidak negatif",
+				"errors":  nil,
+				"data":    nil,
+			})
+		}
+		cfg.Retention = time.Duration(days) * 24 * time.Hour
+	}
+
+	count, err := services.PurgeArchivedProducts(cfg.Retention, cfg.BatchSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menghapus produk yang diarsipkan",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil menghapus permanen produk yang diarsipkan",
+		"errors":  nil,
+		"data": fiber.Map{
+			"jumlah_dihapus": count,
+		},
+	})
+}
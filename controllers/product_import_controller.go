@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// productSheetErrorCode memetakan error impor dan ekspor katalog ke HTTP status
+func productSheetErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAksesProdukDitolak):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrFormatFileTidakDikenal), errors.Is(err, services.ErrFileImporTidakValid),
+		errors.Is(err, services.ErrFileImporKosong), errors.Is(err, services.ErrKolomImporTidakLengkap),
+		errors.Is(err, services.ErrBarisImporTerlaluBanyak):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Import Products
+// @Summary Import Products
+// @Description Create or update the current user's products from a CSV or XLSX file with the columns slug, sku, nama_produk, nama_varian, deskripsi, id_category, harga_reseller, harga_konsumen, stok, stok_minimum, berat (same format as the export). Rows without sku are matched to the store's products by slug (generated from nama_produk when empty) and created when missing; empty cells keep the current value and a harga_reseller of 0 means resellers pay the consumer price. Rows with sku update the price and stock of an existing variant and never create one: variants need option values, so they are created through PUT /product/{id}/variants and rows with an unknown sku are rejected. a price of 0 makes the variant follow the product price. Every row is validated and reported without locking products; changes are saved only when all rows are valid, in batches of IMPORT_BATCH_SIZE rows (default 100). If a batch fails because the data changed meanwhile, earlier batches stay saved and each row reports whether it was applied. Use dry_run to validate without saving.
+// @Tags Product
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV or XLSX file"
+// @Param dry_run formData bool false "Validate and report without saving"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 422 {object} Response
+// @Router /product/import [post]
+func ImportProducts(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "File impor wajib diunggah",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal membuka file",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal membaca file",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	dryRun, _ := strconv.ParseBool(c.FormValue("dry_run", c.Query("dry_run")))
+
+	result, err := services.ImportProducts(userID, file.Filename, data, dryRun)
+	if err != nil {
+		return c.Status(productSheetErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengimpor produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	if result.Gagal > 0 {
+		message := "Impor tidak disimpan, periksa kesalahan pada setiap baris"
+		if result.JumlahDiterapkan > 0 {
+			message = "Impor terhenti, sebagian baris sudah disimpan; periksa kesalahan pada setiap baris"
+		}
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  false,
+			"message": message,
+			"errors":  nil,
+			"data":    result,
+		})
+	}
+
+	message := "Berhasil mengimpor produk"
+	if result.DryRun {
+		message = "Semua baris valid, tidak ada perubahan yang disimpan karena dry run"
+	}
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": message,
+		"errors":  nil,
+		"data":    result,
+	})
+}
+
+// Export Products
+// @Summary Export Products
+// @Description Download the current user's product catalogue as CSV or XLSX in the import format. Products with variants are written as a product row without stock followed by one row per variant.
+// @Tags Product
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param format query string false "csv or xlsx" default(csv)
+// @Success 200 {file} file
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /product/export [get]
+func ExportProducts(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	export, err := services.ExportProducts(userID, c.Query("format", services.SheetFormatCSV))
+	if err != nil {
+		return c.Status(productSheetErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengekspor produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, export.ContentType)
+	c.Attachment(export.Filename)
+	return c.Send(export.Data)
+}
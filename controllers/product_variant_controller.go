@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// variantErrorCode memetakan error dari service varian produk ke HTTP status
+func variantErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrProdukTidakDitemukan), errors.Is(err, services.ErrVarianTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrAksesProdukDitolak):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrDataVarianTidakValid):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrSKUSudahDipakai):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// parseOptionalInt membaca nilai angka opsional dari form-data. Field yang tidak dikirim bernilai nil.
+func parseOptionalInt(c *fiber.Ctx, key string) (*int, error) {
+	raw := c.FormValue(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// Get Product Variants
+// @Summary Get Product Variants
+// @Description Get the option types and variant combinations of a product.
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Router /product/{id}/variants [get]
+func GetProductVariants(c *fiber.Ctx) error {
+	produkID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID produk tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	variants, err := services.GetProductVariants(uint(produkID))
+	if err != nil {
+		return c.Status(variantErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil varian produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil varian produk",
+		"errors":  nil,
+		"data":    variants,
+	})
+}
+
+// Replace Product Variants
+// @Summary Replace Product Variants
+// @Description Replace the option types and variants of the current user's product. Variants are matched by SKU and updated in place; variants missing from the request are removed. Send empty opsi and varian to sell the product without variants.
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param request body services.ProductVariantsInput true "Options and variants"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /product/{id}/variants [put]
+func ReplaceProductVariants(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produkID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID produk tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var input services.ProductVariantsInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	variants, err := services.ReplaceProductVariants(userID, uint(produkID), input)
+	if err != nil {
+		return c.Status(variantErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menyimpan varian produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Varian produk berhasil disimpan",
+		"errors":  nil,
+		"data":    variants,
+	})
+}
+
+// Update Product Variant
+// @Summary Update Product Variant
+// @Description Update the price override, stock or photo of one variant. Only the fields sent are changed; a price of 0 makes the variant follow the product price again.
+// @Tags Product
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Param harga_reseller formData int false "Reseller price override"
+// @Param harga_konsumen formData int false "Consumer price override"
+// @Param stok formData int false "Variant stock"
+// @Param photo formData file false "Variant photo"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /product/{id}/variants/{variantId} [put]
+func UpdateProductVariant(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produkID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID produk tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+	varianID, err := strconv.Atoi(c.Params("variantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID varian tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var input services.VariantUpdate
+	for key, target := range map[string]**int{
+		"harga_reseller": &input.HargaReseller,
+		"harga_konsumen": &input.HargaKonsumen,
+		"stok":           &input.Stok,
+	} {
+		v, err := parseOptionalInt(c, key)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Nilai " + key + " tidak valid",
+				"errors":  err.Error(),
+				"data":    nil,
+			})
+		}
+		*target = v
+	}
+
+	// Foto varian diunggah ke Cloudinary seperti foto produk
+	if file, _ := c.FormFile("photo"); file != nil {
+		src, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  false,
+				"message": "Gagal membuka file",
+				"errors":  err.Error(),
+				"data":    nil,
+			})
+		}
+		defer src.Close()
+
+		input.URLFoto, err = services.UploadToCloudinary(src)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  false,
+				"message": "Gagal mengunggah foto varian",
+				"errors":  err.Error(),
+				"data":    nil,
+			})
+		}
+	}
+
+	varian, err := services.UpdateVariant(userID, uint(produkID), uint(varianID), input)
+	if err != nil {
+		return c.Status(variantErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal memperbarui varian produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Varian produk berhasil diperbarui",
+		"errors":  nil,
+		"data":    varian,
+	})
+}
+
+// Delete Product Variant
+// @Summary Delete Product Variant
+// @Description Delete one variant of the current user's product. Deleting the last variant makes the product sellable without variants again.
+// @Tags Product
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Product ID"
+// @Param variantId path int true "Variant ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /product/{id}/variants/{variantId} [delete]
+func DeleteProductVariant(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	produkID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID produk tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+	varianID, err := strconv.Atoi(c.Params("variantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID varian tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	if err := services.DeleteVariant(userID, uint(produkID), uint(varianID)); err != nil {
+		return c.Status(variantErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menghapus varian produk",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Varian produk berhasil dihapus",
+		"errors":  nil,
+		"data":    nil,
+	})
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/models"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// refundErrorCode memetakan error dari service refund ke HTTP status
+func refundErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrRefundTidakDitemukan), errors.Is(err, services.ErrTransaksiTidakDitemukan),
+		errors.Is(err, services.ErrDetailTransaksiTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrAksesTransaksiDitolak):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrStatusRefundTidakValid), errors.Is(err, services.ErrTransaksiTidakDapatDirefund):
+		return fiber.StatusConflict
+	case errors.Is(err, services.ErrAlasanRefundKosong), errors.Is(err, services.ErrKuantitasTidakValid),
+		errors.Is(err, services.ErrKuantitasRefundMelebihi):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Create Refund
+// @Summary Create Refund
+// @Description Request a refund for all or part of the quantity of one item in a paid transaction.
+// @Tags Refund
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param request body services.RefundRequest true "Refund request"
+// @Success 201 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /trx/{id}/refunds [post]
+func CreateRefund(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trxID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var req services.RefundRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	refund, err := services.CreateRefund(uint(trxID), userID, req)
+	if err != nil {
+		return c.Status(refundErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengajukan refund",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  true,
+		"message": "Refund berhasil diajukan",
+		"errors":  nil,
+		"data":    refund,
+	})
+}
+
+// Get Transaction Refunds
+// @Summary Get Transaction Refunds
+// @Description Get the refunds of a transaction. Sellers only see refunds for their own store's items.
+// @Tags Refund
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Router /trx/{id}/refunds [get]
+func GetTransactionRefunds(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trxID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	refunds, err := services.GetTransactionRefunds(uint(trxID), userID)
+	if err != nil {
+		return c.Status(refundErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil refund",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil refund",
+		"errors":  nil,
+		"data":    refunds,
+	})
+}
+
+// Get Refunds
+// @Summary Get Refunds
+// @Description Get refunds requested by the current user and refunds for the current user's store. Admins see all refunds.
+// @Tags Refund
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by refund status"
+// @Param limit query int false "Limit per page" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 500 {object} Response
+// @Router /refunds [get]
+func GetRefunds(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	status := c.Query("status")
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	refunds, total, totalPages, err := services.GetRefunds(userID, status, page, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil refund",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil refund",
+		"errors":  nil,
+		"data":    refunds,
+		"pagination": fiber.Map{
+			"page":       page,
+			"limit":      limit,
+			"total_data": total,
+			"total_page": totalPages,
+		},
+	})
+}
+
+// transitionRefund menangani perpindahan status refund ke status `to`
+func transitionRefund(c *fiber.Ctx, to, successMessage string) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	refundID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID refund tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	// Keputusan bersifat opsional: restock dan catatan
+	var decision services.RefundDecision
+	_ = c.BodyParser(&decision)
+
+	refund, err := services.TransitionRefund(uint(refundID), userID, to, decision)
+	if err != nil {
+		return c.Status(refundErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengubah status refund",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": successMessage,
+		"errors":  nil,
+		"data":    refund,
+	})
+}
+
+// Approve Refund
+// @Summary Approve Refund
+// @Description Approve a refund as the seller of the item's store, or as admin. Admins can also approve a refund the seller rejected. Approval deducts the refund from the order totals and optionally restocks the product.
+// @Tags Refund
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Refund ID"
+// @Param request body services.RefundDecision false "Restock flag and note"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /refunds/{id}/approve [put]
+func ApproveRefund(c *fiber.Ctx) error {
+	return transitionRefund(c, models.RefundStatusApproved, "Refund berhasil disetujui")
+}
+
+// Reject Refund
+// @Summary Reject Refund
+// @Description Reject a refund as the seller of the item's store, or as admin.
+// @Tags Refund
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Refund ID"
+// @Param request body services.RefundDecision false "Note"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /refunds/{id}/reject [put]
+func RejectRefund(c *fiber.Ctx) error {
+	return transitionRefund(c, models.RefundStatusRejected, "Refund berhasil ditolak")
+}
+
+// Complete Refund
+// @Summary Complete Refund
+// @Description Mark an approved refund as paid back to the buyer (Admin only).
+// @Tags Refund
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Refund ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /refunds/{id}/complete [put]
+func CompleteRefund(c *fiber.Ctx) error {
+	return transitionRefund(c, models.RefundStatusRefunded, "Refund berhasil dikembalikan")
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// Apply Reseller
+// @summary Apply as Reseller
+// @description Submit the current user as a reseller. Reseller prices apply at checkout once an admin verifies the application.
+// @tags User
+// @accept json
+// @produce json
+// @security BearerAuth
+// @success 200 {object} Response
+// @failure 400 {object} Response
+// @failure 401 {object} Response
+// @router /user/reseller [post]
+func ApplyReseller(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	user, err := services.ApplyReseller(userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengajukan reseller",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Pengajuan reseller berhasil dikirim",
+		"errors":  nil,
+		"data":    user,
+	})
+}
+
+// Verify Reseller (Admin Only)
+// @summary Verify Reseller
+// @description Approve or reject a user's reseller application (Admin only).
+// @tags User
+// @accept json
+// @produce json
+// @security BearerAuth
+// @param id path int true "User ID"
+// @param request body object{status=string} true "verified or rejected"
+// @success 200 {object} Response
+// @failure 400 {object} Response
+// @failure 401 {object} Response
+// @failure 403 {object} Response
+// @router /user/{id}/reseller [put]
+func VerifyReseller(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	user, err := services.VerifyReseller(uint(id), req.Status)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal memperbarui status reseller",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Status reseller berhasil diperbarui",
+		"errors":  nil,
+		"data":    user,
+	})
+}
+
+// Get Reseller Margin Report
+// @summary Get Reseller Margin Report
+// @description Get the current reseller's margin from dropship orders for payout reporting.
+// @tags User
+// @accept json
+// @produce json
+// @security BearerAuth
+// @param date_from query string false "Created on or after date (YYYY-MM-DD)"
+// @param date_to query string false "Created on or before date (YYYY-MM-DD)"
+// @success 200 {object} Response
+// @failure 400 {object} Response
+// @failure 401 {object} Response
+// @failure 500 {object} Response
+// @router /user/reseller/margin [get]
+func GetResellerMarginReport(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	dateFrom, dateTo, err := parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Format tanggal harus YYYY-MM-DD",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	report, err := services.GetResellerMarginReport(userID, dateFrom, dateTo)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil laporan margin",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil laporan margin",
+		"errors":  nil,
+		"data":    report,
+	})
+}
@@ -137,6 +137,9 @@ func GetStoreByID(c *fiber.Ctx) error {
 // @Security BearerAuth
 // @param id path int true "Store ID"
 // @param nama_toko formData string false "Store Name"
+// @param id_provinsi formData string false "Province ID of the shipping origin"
+// @param id_kota formData string false "City ID of the shipping origin"
+// @param digest_stok formData bool false "Send low-stock alerts as a daily digest instead of one by one"
 // @param photo formData file false "Store Photo (Upload Image File)"
 // @success 200 {object} Response
 // @failure 400 {object} Response
@@ -198,14 +201,30 @@ func UpdateStore(c *fiber.Ctx) error {
 
 	// Siapkan data update
 	updateData := models.Toko{
-		NamaToko: namaToko,
+		NamaToko:   namaToko,
+		IDProvinsi: c.FormValue("id_provinsi"),
+		IDKota:     c.FormValue("id_kota"),
 	}
 	if photoURL != "" {
 		updateData.URLFoto = photoURL
 	}
 
+	// Pilihan ringkasan stok harian bisa dimatikan, sehingga dikirim terpisah dari updateData
+	var digestStok *bool
+	if raw := c.FormValue("digest_stok"); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Nilai digest_stok tidak valid",
+				"errors":  err.Error(),
+			})
+		}
+		digestStok = &v
+	}
+
 	// Panggil service untuk update store
-	store, err := services.UpdateStore(userID, uint(storeID), updateData)
+	store, err := services.UpdateStore(userID, uint(storeID), updateData, digestStok)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  false,
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// Get My Store Sub Transactions
+// @Summary Get My Store Sub Transactions
+// @Description Get the per-store sub-orders that belong to the current user's store.
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by sub-order status"
+// @Param limit query int false "Limit per page" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /toko/my/suborders [get]
+func GetMyStoreSubTransactions(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	status := c.Query("status")
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
+
+	subs, total, totalPages, err := services.GetStoreSubTransactions(userID, status, page, limit)
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		if errors.Is(err, services.ErrTokoTidakDitemukan) {
+			code = fiber.StatusNotFound
+		}
+		return c.Status(code).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil sub-transaksi toko",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil sub-transaksi toko",
+		"errors":  nil,
+		"data":    subs,
+		"pagination": fiber.Map{
+			"page":       page,
+			"limit":      limit,
+			"total_data": total,
+			"total_page": totalPages,
+		},
+	})
+}
+
+// Get My Store Sub Transaction by ID
+// @Summary Get My Store Sub Transaction by ID
+// @Description Get a sub-order of the current user's store with its items and shipping address.
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Sub-order ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Router /toko/my/suborders/{id} [get]
+func GetMyStoreSubTransactionByID(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	subID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID sub-transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	sub, err := services.GetStoreSubTransactionByID(userID, uint(subID))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  false,
+			"message": "Sub-transaksi tidak ditemukan",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil sub-transaksi",
+		"errors":  nil,
+		"data":    sub,
+	})
+}
+
+// Get My Store Orders
+// @Summary Get My Store Orders
+// @Description Seller order inbox: orders containing the current user's products, with only their own line items.
+// @Tags Store
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Filter by the store's sub-order status"
+// @Param search query string false "Search by invoice code"
+// @Param date_from query string false "Created on or after date (YYYY-MM-DD)"
+// @Param date_to query string false "Created on or before date (YYYY-MM-DD)"
+// @Param limit query int false "Limit per page" default(10)
+// @Param page query int false "Page number" default(1)
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /toko/my/orders [get]
+func GetMyStoreOrders(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	filter := services.SellerOrderFilter{
+		Status: c.Query("status"),
+		Search: c.Query("search"),
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit", "10"))
+	filter.Page, _ = strconv.Atoi(c.Query("page", "1"))
+	if filter.Limit <= 0 {
+		filter.Limit = 10
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	filter.DateFrom, filter.DateTo, err = parseDateRange(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Format tanggal harus YYYY-MM-DD",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	orders, total, totalPages, err := services.GetSellerOrders(userID, filter)
+	if err != nil {
+		code := fiber.StatusInternalServerError
+		if errors.Is(err, services.ErrTokoTidakDitemukan) {
+			code = fiber.StatusNotFound
+		}
+		return c.Status(code).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil pesanan toko",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil pesanan toko",
+		"errors":  nil,
+		"data":    orders,
+		"pagination": fiber.Map{
+			"page":       filter.Page,
+			"limit":      filter.Limit,
+			"total_data": total,
+			"total_page": totalPages,
+		},
+	})
+}
+
+// parseDateRange membaca query date_from dan date_to dengan format YYYY-MM-DD
+func parseDateRange(c *fiber.Ctx) (*time.Time, *time.Time, error) {
+	var dates [2]*time.Time
+	for i, param := range []string{"date_from", "date_to"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		date, err := time.ParseInLocation("2006-01-02", value, time.Local)
+		if err != nil {
+			return nil, nil, err
+		}
+		dates[i] = &date
+	}
+	return dates[0], dates[1], nil
+}
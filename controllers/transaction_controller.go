@@ -1,15 +1,15 @@
 package controllers
 
 import (
-	"fmt"
+	"errors"
 	"math"
 	"strconv"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/habbazettt/evermos-service-go/config"
 	"github.com/habbazettt/evermos-service-go/middleware"
 	"github.com/habbazettt/evermos-service-go/models"
+	"github.com/habbazettt/evermos-service-go/services"
 )
 
 // Create Transaction
@@ -19,7 +19,10 @@ import (
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body object{method_bayar=string, alamat_kirim=uint, detail_transaksi=[]object{product_id=uint, kuantitas=int}} true "Transaction Data"
+// @Param request body services.CheckoutRequest true "Transaction Data"
+// @Param Idempotency-Key header string false "Unique key to safely retry the request"
+// @Failure 409 {object} Response
+// @Failure 422 {object} Response
 // @Success 201 {object} Response
 // @Failure 400 {object} Response
 // @Failure 401 {object} Response
@@ -34,131 +37,78 @@ func CreateTransaction(c *fiber.Ctx) error {
 	}
 
 	// Parsing request body
-	var req struct {
-		MethodBayar      string `json:"method_bayar"`
-		AlamatPengiriman uint   `json:"alamat_kirim"`
-		DetailTransaksi  []struct {
-			ProductID uint `json:"product_id"`
-			Kuantitas int  `json:"kuantitas"`
-		} `json:"detail_transaksi"`
-	}
-
+	var req services.CheckoutRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body"})
 	}
 
-	// Periksa apakah alamat kirim valid
-	var alamat models.Alamat
-	if err := config.DB.Where("id = ? AND id_user = ?", req.AlamatPengiriman, userID).First(&alamat).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Alamat tidak ditemukan"})
+	trx, err := services.CreateTransaction(userID, req)
+	if err != nil {
+		return c.Status(checkoutErrorCode(err)).JSON(fiber.Map{"message": checkoutErrorMessage(err)})
 	}
 
-	// Generate kode invoice unik
-	kodeInvoice := fmt.Sprintf("INV-%d", time.Now().Unix())
-
-	// Inisialisasi transaksi baru
-	transaction := models.Transaction{
-		IDUser:           userID,
-		AlamatPengiriman: req.AlamatPengiriman,
-		KodeInvoice:      kodeInvoice,
-		MethodBayar:      req.MethodBayar,
-		HargaTotal:       0,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
-	}
+	// **Return response sukses dengan format lengkap**
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Transaksi berhasil dibuat",
+		"data":    formatCreatedTransaction(trx),
+	})
+}
 
-	// Simpan transaksi ke database
-	tx := config.DB.Begin()
-	if err := tx.Create(&transaction).Error; err != nil {
-		tx.Rollback()
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menyimpan transaksi"})
+// Quote Transaction
+// @Summary Quote Transaction
+// @Description Dry run of checkout: returns totals, per-store subtotals and per-line errors using the same pricing and validation as Create Transaction, without creating a transaction or reserving stock. method_bayar and alamat_kirim are only validated when provided.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.CheckoutRequest true "Transaction Data"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 500 {object} Response
+// @Router /trx/quote [post]
+func QuoteTransaction(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
 	}
 
-	// Proses detail transaksi
-	var totalHarga int
-	var detailTrxList []models.DetailTransaction
-
-	for _, detail := range req.DetailTransaksi {
-		// Cek apakah produk ada di tabel Produk
-		var produk models.Produk
-		if err := tx.Preload("FotoProduk").First(&produk, detail.ProductID).Error; err != nil {
-			tx.Rollback()
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Produk tidak ditemukan"})
-		}
-
-		// Pastikan stok mencukupi
-		if produk.Stok < detail.Kuantitas {
-			tx.Rollback()
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Stok produk tidak mencukupi"})
-		}
-
-		// Cek apakah sudah ada di LogProduk
-		var logProduk models.LogProduk
-		if err := tx.Where("id_produk = ?", produk.ID).First(&logProduk).Error; err != nil {
-			// Jika belum ada, buat entri baru di LogProduk
-			logProduk = models.LogProduk{
-				IDProduk:      produk.ID,
-				NamaProduk:    produk.NamaProduk,
-				Slug:          produk.Slug,
-				HargaReseller: produk.HargaReseller,
-				HargaKonsumen: produk.HargaKonsumen,
-				Deskripsi:     produk.Deskripsi,
-				IDToko:        produk.IDToko,
-				IDCategory:    produk.IDCategory,
-				CreatedAt:     time.Now(),
-				UpdatedAt:     time.Now(),
-			}
-
-			if err := tx.Create(&logProduk).Error; err != nil {
-				tx.Rollback()
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menyimpan ke LogProduk"})
-			}
-		}
-
-		// Hitung harga total
-		hargaTotal := produk.HargaKonsumen * detail.Kuantitas
-		totalHarga += hargaTotal
-
-		// Simpan detail transaksi
-		detailTrx := models.DetailTransaction{
-			IDTrx:       transaction.ID,
-			IDLogProduk: logProduk.ID, // Pakai ID dari LogProduk
-			IDToko:      produk.IDToko,
-			Kuantitas:   detail.Kuantitas,
-			HargaTotal:  hargaTotal,
-			CreatedAt:   time.Now(),
-			UpdatedAt:   time.Now(),
-		}
-
-		if err := tx.Create(&detailTrx).Error; err != nil {
-			tx.Rollback()
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal menyimpan detail transaksi"})
-		}
-
-		detailTrxList = append(detailTrxList, detailTrx)
-
-		// Update stok produk
-		produk.Stok -= detail.Kuantitas
-		if err := tx.Save(&produk).Error; err != nil {
-			tx.Rollback()
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal memperbarui stok produk"})
-		}
+	var req services.CheckoutRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
 	}
 
-	// Update total harga transaksi
-	transaction.HargaTotal = totalHarga
-	if err := tx.Save(&transaction).Error; err != nil {
-		tx.Rollback()
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal memperbarui total harga transaksi"})
+	quote, err := services.QuoteTransaction(userID, req)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menghitung transaksi",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
 	}
 
-	tx.Commit()
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil menghitung transaksi",
+		"errors":  nil,
+		"data":    quote,
+	})
+}
 
-	// Ambil transaksi yang baru dibuat
-	var trx models.Transaction
-	if err := config.DB.Preload("DetailTransaksi.LogProduct").Preload("Alamat").First(&trx, transaction.ID).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal mengambil transaksi yang baru dibuat"})
-	}
+// formatCreatedTransaction menyusun response transaksi yang baru dibuat beserta detail lengkapnya
+func formatCreatedTransaction(trx *models.Transaction) map[string]interface{} {
+	alamat := trx.Alamat
 
 	// Format detail transaksi dengan informasi lengkap
 	formattedDetailTrx := []map[string]interface{}{}
@@ -169,7 +119,11 @@ func CreateTransaction(c *fiber.Ctx) error {
 			"id_log_produk": detail.IDLogProduk,
 			"id_toko":       detail.IDToko,
 			"kuantitas":     detail.Kuantitas,
+			"tier_harga":    detail.TierHarga,
+			"harga_satuan":  detail.HargaSatuan,
 			"harga_total":   detail.HargaTotal,
+			"harga_jual":    detail.HargaJual,
+			"margin":        detail.Margin,
 			"created_at":    detail.CreatedAt,
 			"updated_at":    detail.UpdatedAt,
 			"log_product": map[string]interface{}{
@@ -188,24 +142,78 @@ func CreateTransaction(c *fiber.Ctx) error {
 		})
 	}
 
-	// **Return response sukses dengan format lengkap**
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"message": "Transaksi berhasil dibuat",
-		"data": map[string]interface{}{
-			"id":           trx.ID,
-			"harga_total":  trx.HargaTotal,
-			"kode_invoice": trx.KodeInvoice,
-			"method_bayar": trx.MethodBayar,
-			"alamat_kirim": map[string]interface{}{
-				"id":            trx.AlamatPengiriman,
-				"judul_alamat":  alamat.JudulAlamat,
-				"nama_penerima": alamat.NamaPenerima,
-				"no_telp":       alamat.NoTelp,
-				"detail_alamat": alamat.DetailAlamat,
-			},
-			"detail_trx": formattedDetailTrx,
+	return map[string]interface{}{
+		"id":           trx.ID,
+		"harga_total":  trx.HargaTotal,
+		"kode_invoice": trx.KodeInvoice,
+		"method_bayar": trx.MethodBayar,
+		"status":       trx.Status,
+		"alamat_kirim": map[string]interface{}{
+			"id":            trx.AlamatPengiriman,
+			"judul_alamat":  alamat.JudulAlamat,
+			"nama_penerima": alamat.NamaPenerima,
+			"no_telp":       alamat.NoTelp,
+			"detail_alamat": alamat.DetailAlamat,
 		},
-	})
+		"is_dropship": trx.IsDropship,
+		"dropship": map[string]interface{}{
+			"nama":    trx.DropshipNama,
+			"no_telp": trx.DropshipNoTelp,
+			"alamat":  trx.DropshipAlamat,
+		},
+		"kode_voucher":  trx.KodeVoucher,
+		"diskon":        trx.Diskon,
+		"detail_trx":    formattedDetailTrx,
+		"sub_transaksi": trx.SubTransaksi,
+	}
+}
+
+// checkoutErrorCode memetakan error checkout ke HTTP status
+func checkoutErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrAlamatTidakDitemukan), errors.Is(err, services.ErrProdukTidakDitemukan),
+		errors.Is(err, services.ErrVoucherTidakDitemukan), errors.Is(err, services.ErrVarianTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrStokTidakMencukupi), errors.Is(err, services.ErrKuantitasTidakValid),
+		errors.Is(err, services.ErrVarianWajibDipilih),
+		errors.Is(err, services.ErrDetailTransaksiKosong), errors.Is(err, services.ErrDataDropshipKosong),
+		errors.Is(err, services.ErrHargaJualTidakValid), errors.Is(err, services.ErrProviderPembayaranTidakDikenal):
+		return fiber.StatusBadRequest
+	case errors.Is(err, services.ErrDropshipBukanReseller):
+		return fiber.StatusForbidden
+	case isVoucherError(err):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// isVoucherError mengecek apakah voucher ditolak karena syarat pemakaiannya tidak terpenuhi
+func isVoucherError(err error) bool {
+	return errors.Is(err, services.ErrVoucherTidakBerlaku) || errors.Is(err, services.ErrVoucherMinBelanja) ||
+		errors.Is(err, services.ErrVoucherProdukTidakSesuai) || errors.Is(err, services.ErrKuotaVoucherHabis) ||
+		errors.Is(err, services.ErrKuotaVoucherUserHabis)
+}
+
+// checkoutErrorMessage menyusun pesan error checkout untuk client
+func checkoutErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, services.ErrAlamatTidakDitemukan):
+		return "Alamat tidak ditemukan"
+	case errors.Is(err, services.ErrProdukTidakDitemukan):
+		return "Produk tidak ditemukan"
+	case errors.Is(err, services.ErrStokTidakMencukupi), errors.Is(err, services.ErrKuantitasTidakValid):
+		return "Stok produk tidak mencukupi"
+	case errors.Is(err, services.ErrDetailTransaksiKosong):
+		return "Detail transaksi tidak boleh kosong"
+	case errors.Is(err, services.ErrDropshipBukanReseller), errors.Is(err, services.ErrDataDropshipKosong),
+		errors.Is(err, services.ErrHargaJualTidakValid), errors.Is(err, services.ErrProviderPembayaranTidakDikenal),
+		errors.Is(err, services.ErrVoucherTidakDitemukan), isVoucherError(err),
+		errors.Is(err, services.ErrVarianTidakDitemukan), errors.Is(err, services.ErrVarianWajibDipilih):
+		return err.Error()
+	default:
+		return "Gagal menyimpan transaksi"
+	}
 }
 
 // Get All Transactions
@@ -216,35 +224,62 @@ func CreateTransaction(c *fiber.Ctx) error {
 // @Produce json
 // @Security BearerAuth
 // @Param search query string false "Search transactions by invoice code"
+// @Param status query string false "Filter by transaction status"
 // @Param limit query int false "Limit per page" default(10)
 // @Param page query int false "Page number" default(1)
 // @Success 200 {object} Response
 // @Failure 500 {object} Response
 // @Router /trx [get]
 func GetAllTransactions(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Unauthorized"})
+	}
+
+	actor, err := services.ResolveActor(userID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "User tidak ditemukan"})
+	}
+
 	// Ambil query params
 	search := c.Query("search")                      // Filter berdasarkan kode invoice
+	status := c.Query("status")                      // Filter berdasarkan status transaksi
 	limit, _ := strconv.Atoi(c.Query("limit", "10")) // Default 10
 	page, _ := strconv.Atoi(c.Query("page", "1"))    // Default 1
+	if limit <= 0 {
+		limit = 10
+	}
+	if page <= 0 {
+		page = 1
+	}
 	offset := (page - 1) * limit
 
-	// Query transaksi
-	var transactions []models.Transaction
-	query := config.DB.Preload("DetailTransaksi.LogProduct").Preload("Alamat")
+	// Query transaksi sesuai hak akses user
+	query := actor.ScopeTransactions(config.DB.Model(&models.Transaction{}))
 
 	// Jika ada parameter search, filter berdasarkan kode_invoice
 	if search != "" {
 		query = query.Where("kode_invoice LIKE ?", "%"+search+"%")
 	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	// Hitung total transaksi untuk pagination
+	var total int64
+	query.Count(&total)
 
 	// Eksekusi query dengan pagination
-	if err := query.Limit(limit).Offset(offset).Find(&transactions).Error; err != nil {
+	var transactions []models.Transaction
+	if err := query.Preload("DetailTransaksi.LogProduct").Preload("SubTransaksi").Preload("Alamat").
+		Order("id DESC").Limit(limit).Offset(offset).Find(&transactions).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Gagal mengambil transaksi"})
 	}
 
-	// Hitung total transaksi untuk pagination
-	var total int64
-	config.DB.Model(&models.Transaction{}).Where("kode_invoice LIKE ?", "%"+search+"%").Count(&total)
+	// Penjual hanya melihat item dan nilai pesanan dari tokonya sendiri
+	for i := range transactions {
+		actor.ProjectTransaction(&transactions[i])
+	}
 
 	// Response
 	return c.JSON(fiber.Map{
@@ -273,23 +308,33 @@ func GetAllTransactions(c *fiber.Ctx) error {
 // @Failure 500 {object} Response
 // @Router /trx/{id} [get]
 func GetTransactionByID(c *fiber.Ctx) error {
-	// Ambil query params
-	transactionID := c.Params("id")
-	limit, _ := strconv.Atoi(c.Query("limit", "10")) // Default 10
-	page, _ := strconv.Atoi(c.Query("page", "1"))    // Default 1
-	offset := (page - 1) * limit
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Unauthorized"})
+	}
+
+	actor, err := services.ResolveActor(userID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "User tidak ditemukan"})
+	}
+
+	transactionID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "ID transaksi tidak valid"})
+	}
 
 	// Query transaksi berdasarkan ID
 	var transaction models.Transaction
-	query := config.DB.Preload("DetailTransaksi.LogProduct").Preload("Alamat")
-
-	// Cari transaksi dengan parameter ID
-	query = query.Where("id = ?", transactionID)
+	if err := config.DB.Preload("DetailTransaksi.LogProduct").Preload("SubTransaksi").Preload("Alamat").
+		First(&transaction, transactionID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Transaksi tidak ditemukan"})
+	}
 
-	// Eksekusi query dengan pagination
-	if err := query.Limit(limit).Offset(offset).First(&transaction).Error; err != nil {
+	// Transaksi milik orang lain diperlakukan seperti tidak ada
+	if !actor.CanViewTransaction(config.DB, &transaction) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Transaksi tidak ditemukan"})
 	}
+	actor.ProjectTransaction(&transaction)
 
 	// Response dengan format yang sesuai
 	return c.JSON(fiber.Map{
@@ -297,8 +342,10 @@ func GetTransactionByID(c *fiber.Ctx) error {
 		"data": map[string]interface{}{
 			"id":           transaction.ID,
 			"harga_total":  transaction.HargaTotal,
+			"total_refund": transaction.TotalRefund,
 			"kode_invoice": transaction.KodeInvoice,
 			"method_bayar": transaction.MethodBayar,
+			"status":       transaction.Status,
 			"alamat_kirim": map[string]interface{}{
 				"id":            transaction.Alamat.ID,
 				"judul_alamat":  transaction.Alamat.JudulAlamat,
@@ -306,7 +353,8 @@ func GetTransactionByID(c *fiber.Ctx) error {
 				"no_telp":       transaction.Alamat.NoTelp,
 				"detail_alamat": transaction.Alamat.DetailAlamat,
 			},
-			"detail_trx": transaction.DetailTransaksi,
+			"detail_trx":    transaction.DetailTransaksi,
+			"sub_transaksi": transaction.SubTransaksi,
 		},
 	})
 }
@@ -0,0 +1,355 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/models"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// transactionStatusErrorCode memetakan error dari service status transaksi ke HTTP status
+func transactionStatusErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrTransaksiTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrAksesTransaksiDitolak):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrTransisiTidakValid), errors.Is(err, services.ErrTransaksiSudahDikirim):
+		return fiber.StatusConflict
+	case errors.Is(err, services.ErrAlasanBatalKosong), errors.Is(err, services.ErrNoResiKosong),
+		errors.Is(err, services.ErrKurirTidakDikenal):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// transitionTransaction menangani perpindahan status transaksi ke status `to`
+func transitionTransaction(c *fiber.Ctx, to, successMessage string) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trxID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	// Catatan bersifat opsional, kurir dan nomor resi wajib saat pengiriman
+	var req struct {
+		Catatan string `json:"catatan"`
+		NoResi  string `json:"no_resi"`
+		Kurir   string `json:"kurir"`
+	}
+	_ = c.BodyParser(&req)
+
+	trx, err := services.TransitionTransaction(uint(trxID), userID, to, services.TransitionInput{
+		Catatan: req.Catatan,
+		NoResi:  req.NoResi,
+		Kurir:   req.Kurir,
+	})
+	if err != nil {
+		return c.Status(transactionStatusErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengubah status transaksi",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": successMessage,
+		"errors":  nil,
+		"data": fiber.Map{
+			"id":           trx.ID,
+			"kode_invoice": trx.KodeInvoice,
+			"status":       trx.Status,
+		},
+	})
+}
+
+// Pay Transaction
+// @Summary Mark Transaction as Paid
+// @Description Admin manually confirms payment of a pending transaction. Payments made through a payment provider are confirmed automatically.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param request body object{catatan=string} false "Optional note"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /trx/{id}/pay [put]
+func PayTransaction(c *fiber.Ctx) error {
+	return transitionTransaction(c, models.TrxStatusPaid, "Pembayaran transaksi berhasil dikonfirmasi")
+}
+
+// Process Transaction
+// @Summary Process Transaction
+// @Description Seller starts processing their store's part of a paid transaction.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param request body object{catatan=string} false "Optional note"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /trx/{id}/process [put]
+func ProcessTransaction(c *fiber.Ctx) error {
+	return transitionTransaction(c, models.TrxStatusProcessing, "Transaksi sedang diproses")
+}
+
+// Ship Transaction
+// @Summary Ship Transaction
+// @Description Seller marks their store's part of a processed transaction as shipped with a courier (jne, jnt, sicepat, anteraja, pos) and tracking number. The first tracking event is recorded automatically.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param request body object{kurir=string, no_resi=string, catatan=string} true "Courier, tracking number and optional note"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /trx/{id}/ship [put]
+func ShipTransaction(c *fiber.Ctx) error {
+	return transitionTransaction(c, models.TrxStatusShipped, "Transaksi telah dikirim")
+}
+
+// Deliver Transaction
+// @Summary Deliver Transaction
+// @Description Mark the seller's shipped part of a transaction as delivered.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param request body object{catatan=string} false "Optional note"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /trx/{id}/deliver [put]
+func DeliverTransaction(c *fiber.Ctx) error {
+	return transitionTransaction(c, models.TrxStatusDelivered, "Transaksi telah diterima")
+}
+
+// Complete Transaction
+// @Summary Complete Transaction
+// @Description Buyer confirms a delivered transaction as completed.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param request body object{catatan=string} false "Optional note"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /trx/{id}/complete [put]
+func CompleteTransaction(c *fiber.Ctx) error {
+	return transitionTransaction(c, models.TrxStatusCompleted, "Transaksi selesai")
+}
+
+// Cancel Transaction
+// @Summary Cancel Transaction
+// @Description Buyer or admin cancels a transaction that has not been shipped and restores product stock. A seller only cancels their own store's sub-order; the whole transaction is cancelled once no other store's sub-order is still active.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Param request body object{alasan=string} true "Cancellation reason"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Failure 409 {object} Response
+// @Router /trx/{id}/cancel [put]
+func CancelTransaction(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trxID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var req struct {
+		Alasan string `json:"alasan"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trx, err := services.CancelTransaction(uint(trxID), userID, req.Alasan)
+	if err != nil {
+		return c.Status(transactionStatusErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal membatalkan transaksi",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Transaksi berhasil dibatalkan",
+		"errors":  nil,
+		"data": fiber.Map{
+			"id":              trx.ID,
+			"kode_invoice":    trx.KodeInvoice,
+			"status":          trx.Status,
+			"alasan_batal":    trx.AlasanBatal,
+			"dibatalkan_pada": trx.DibatalkanPada,
+		},
+	})
+}
+
+// Get Transaction Status History
+// @Summary Get Transaction Status History
+// @Description Get the status history of a transaction.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /trx/{id}/history [get]
+func GetTransactionStatusHistory(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trxID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	history, err := services.GetTransactionStatusHistory(uint(trxID), userID)
+	if err != nil {
+		return c.Status(transactionStatusErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil riwayat status transaksi",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil riwayat status transaksi",
+		"errors":  nil,
+		"data":    history,
+	})
+}
+
+// Get Transaction Tracking
+// @Summary Get Transaction Tracking
+// @Description Get the courier, tracking number and tracking timeline of each store shipment in a transaction. Shipments in transit are refreshed from the courier when a courier provider is configured.
+// @Tags Transaction
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Transaction ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 404 {object} Response
+// @Failure 500 {object} Response
+// @Router /trx/{id}/tracking [get]
+func GetTransactionTracking(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	trxID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID transaksi tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	tracking, err := services.GetTransactionTracking(uint(trxID), userID)
+	if err != nil {
+		return c.Status(transactionStatusErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil data pengiriman",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil data pengiriman",
+		"errors":  nil,
+		"data":    tracking,
+	})
+}
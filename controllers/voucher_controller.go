@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/middleware"
+	"github.com/habbazettt/evermos-service-go/services"
+)
+
+// voucherErrorCode memetakan error dari service voucher ke HTTP status
+func voucherErrorCode(err error) int {
+	switch {
+	case errors.Is(err, services.ErrVoucherTidakDitemukan):
+		return fiber.StatusNotFound
+	case errors.Is(err, services.ErrAksesVoucherDitolak):
+		return fiber.StatusForbidden
+	case errors.Is(err, services.ErrDataVoucherTidakValid):
+		return fiber.StatusBadRequest
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Get Vouchers
+// @Summary Get Vouchers
+// @Description Get the vouchers the current user can manage: all vouchers for admins, the user's own store vouchers for sellers.
+// @Tags Voucher
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /vouchers [get]
+func GetVouchers(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	vouchers, err := services.GetVouchers(userID)
+	if err != nil {
+		return c.Status(voucherErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal mengambil voucher",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Berhasil mengambil voucher",
+		"errors":  nil,
+		"data":    vouchers,
+	})
+}
+
+// Create Voucher
+// @Summary Create Voucher
+// @Description Create a voucher. Admins can create platform-wide vouchers or vouchers for any store; sellers can only create vouchers for their own store.
+// @Tags Voucher
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.VoucherInput true "Voucher data"
+// @Success 201 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Router /vouchers [post]
+func CreateVoucher(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var input services.VoucherInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	voucher, err := services.CreateVoucher(userID, input)
+	if err != nil {
+		return c.Status(voucherErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal membuat voucher",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  true,
+		"message": "Voucher berhasil dibuat",
+		"errors":  nil,
+		"data":    voucher,
+	})
+}
+
+// Update Voucher
+// @Summary Update Voucher
+// @Description Update a voucher owned by the current user's store, or any voucher for admins. The usage counter is not changed.
+// @Tags Voucher
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Voucher ID"
+// @Param request body services.VoucherInput true "Voucher data"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /vouchers/{id} [put]
+func UpdateVoucher(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	voucherID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID voucher tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	var input services.VoucherInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	voucher, err := services.UpdateVoucher(userID, uint(voucherID), input)
+	if err != nil {
+		return c.Status(voucherErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal memperbarui voucher",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Voucher berhasil diperbarui",
+		"errors":  nil,
+		"data":    voucher,
+	})
+}
+
+// Delete Voucher
+// @Summary Delete Voucher
+// @Description Delete a voucher owned by the current user's store, or any voucher for admins.
+// @Tags Voucher
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Voucher ID"
+// @Success 200 {object} Response
+// @Failure 400 {object} Response
+// @Failure 401 {object} Response
+// @Failure 403 {object} Response
+// @Failure 404 {object} Response
+// @Router /vouchers/{id} [delete]
+func DeleteVoucher(c *fiber.Ctx) error {
+	userID, err := middleware.ExtractUserID(c)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  false,
+			"message": "Unauthorized",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	voucherID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  false,
+			"message": "ID voucher tidak valid",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	if err := services.DeleteVoucher(userID, uint(voucherID)); err != nil {
+		return c.Status(voucherErrorCode(err)).JSON(fiber.Map{
+			"status":  false,
+			"message": "Gagal menghapus voucher",
+			"errors":  err.Error(),
+			"data":    nil,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"status":  true,
+		"message": "Voucher berhasil dihapus",
+		"errors":  nil,
+		"data":    nil,
+	})
+}
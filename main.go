@@ -9,14 +9,36 @@ import (
 	"github.com/habbazettt/evermos-service-go/config"
 	_ "github.com/habbazettt/evermos-service-go/docs"
 	"github.com/habbazettt/evermos-service-go/routes"
+	"github.com/habbazettt/evermos-service-go/services"
 )
 
 func main() {
 
 	config.ConnectDB()
 
+	// Format invoice yang tidak unik akan membuat semua checkout gagal, jadi ditolak sejak awal
+	if err := services.LoadInvoiceFormat().Validate(); err != nil {
+		log.Fatalf("Konfigurasi invoice tidak valid: %v", err)
+	}
+
 	config.SetupCloudinary()
 
+	// Jalankan scheduler kadaluarsa transaksi yang belum dibayar
+	stopExpiryScheduler := services.StartOrderExpiryScheduler()
+	defer stopExpiryScheduler()
+
+	// Jalankan polling status pengiriman ke kurir
+	stopTrackingScheduler := services.StartShipmentTrackingScheduler()
+	defer stopTrackingScheduler()
+
+	// Jalankan pengiriman notifikasi dan ringkasan stok harian
+	stopNotificationScheduler := services.StartNotificationScheduler()
+	defer stopNotificationScheduler()
+
+	// Jalankan penghapusan permanen produk yang diarsipkan melewati masa retensi
+	stopPurgeScheduler := services.StartProductPurgeScheduler()
+	defer stopPurgeScheduler()
+
 	app := fiber.New()
 
 	// @title Evermos Store and Product API
@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Masa berlaku Idempotency-Key sebelum boleh dipakai ulang
+	idempotencyKeyTTL = 24 * time.Hour
+	// Batas waktu request yang masih diproses. Key yang tidak selesai setelah lease habis,
+	// misalnya karena server mati di tengah request, boleh dipakai ulang oleh retry.
+	idempotencyLeaseTTL = 2 * time.Minute
+)
+
+// hashIdempotencyRequest menghitung hash request. Body multipart tidak di-hash mentah karena
+// boundary-nya berbeda di setiap retry, sehingga yang di-hash adalah isi field dan digest file.
+func hashIdempotencyRequest(c *fiber.Ctx, endpoint string) (string, error) {
+	h := sha256.New()
+	h.Write([]byte(endpoint + "\n"))
+
+	if !strings.HasPrefix(strings.ToLower(c.Get(fiber.HeaderContentType)), fiber.MIMEMultipartForm) {
+		h.Write(c.Body())
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(form.Value))
+	for name := range form.Value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range form.Value[name] {
+			h.Write([]byte("field\x00" + name + "\x00" + value + "\x00"))
+		}
+	}
+
+	names = names[:0]
+	for name := range form.File {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, fh := range form.File[name] {
+			f, err := fh.Open()
+			if err != nil {
+				return "", err
+			}
+			digest := sha256.New()
+			_, err = io.Copy(digest, f)
+			f.Close()
+			if err != nil {
+				return "", err
+			}
+			h.Write([]byte("file\x00" + name + "\x00" + fh.Filename + "\x00" + hex.EncodeToString(digest.Sum(nil)) + "\x00"))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// IdempotencyMiddleware menyimpan response dari request yang membawa header Idempotency-Key.
+// Retry dengan key dan body yang sama akan menerima response yang tersimpan, sedangkan key yang
+// dipakai ulang dengan body berbeda akan ditolak. Request yang masih diproses dijawab 409 sampai
+// lease-nya habis. Harus dipasang setelah JWTMiddleware.
+func IdempotencyMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get("Idempotency-Key")
+		if key == "" {
+			return c.Next()
+		}
+
+		userID, err := ExtractUserID(c)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  false,
+				"message": "Unauthorized",
+				"errors":  err.Error(),
+				"data":    nil,
+			})
+		}
+
+		if len(key) > 191 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Idempotency-Key terlalu panjang",
+				"errors":  nil,
+				"data":    nil,
+			})
+		}
+
+		endpoint := c.Method() + " " + c.Route().Path
+		requestHash, err := hashIdempotencyRequest(c, endpoint)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  false,
+				"message": "Gagal membaca request",
+				"errors":  err.Error(),
+				"data":    nil,
+			})
+		}
+
+		// Hapus key yang sudah kadaluarsa, atau yang tidak selesai sampai lease habis, agar bisa
+		// dipakai ulang
+		now := time.Now()
+		config.DB.Where("id_user = ? AND endpoint = ? AND kunci = ?", userID, endpoint, key).
+			Where("created_at < ? OR (selesai = ? AND updated_at < ?)",
+				now.Add(-idempotencyKeyTTL), false, now.Add(-idempotencyLeaseTTL)).
+			Delete(&models.IdempotencyKey{})
+
+		record := models.IdempotencyKey{
+			IDUser:      userID,
+			Endpoint:    endpoint,
+			Kunci:       key,
+			RequestHash: requestHash,
+		}
+		result := config.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+		if result.Error != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  false,
+				"message": "Gagal menyimpan Idempotency-Key",
+				"errors":  result.Error.Error(),
+				"data":    nil,
+			})
+		}
+
+		// Key sudah pernah dipakai
+		if result.RowsAffected == 0 {
+			var existing models.IdempotencyKey
+			if err := config.DB.Where("id_user = ? AND endpoint = ? AND kunci = ?", userID, endpoint, key).
+				First(&existing).Error; err != nil {
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"status":  false,
+					"message": "Gagal membaca Idempotency-Key",
+					"errors":  err.Error(),
+					"data":    nil,
+				})
+			}
+
+			if existing.RequestHash != requestHash {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"status":  false,
+					"message": "Idempotency-Key sudah digunakan untuk request yang berbeda",
+					"errors":  nil,
+					"data":    nil,
+				})
+			}
+
+			if !existing.Selesai {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"status":  false,
+					"message": "Request dengan Idempotency-Key ini masih diproses",
+					"errors":  nil,
+					"data":    nil,
+				})
+			}
+
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			return c.Status(existing.StatusCode).SendString(existing.ResponseBody)
+		}
+
+		// Proses request untuk pertama kali
+		err = c.Next()
+		status := c.Response().StatusCode()
+
+		// Jangan simpan kegagalan server agar client bisa mencoba lagi dengan key yang sama
+		if err != nil || status >= fiber.StatusInternalServerError {
+			config.DB.Delete(&record)
+			return err
+		}
+
+		config.DB.Model(&record).Updates(map[string]interface{}{
+			"status_code":   status,
+			"response_body": string(c.Response().Body()),
+			"selesai":       true,
+		})
+
+		return nil
+	}
+}
@@ -9,6 +9,9 @@ type Alamat struct {
 	NamaPenerima string    `json:"nama_penerima"`
 	NoTelp       string    `json:"no_telp"`
 	DetailAlamat string    `json:"detail_alamat"`
+	IDProvinsi   string    `json:"id_provinsi" gorm:"type:varchar(16)"`
+	IDKota       string    `json:"id_kota" gorm:"type:varchar(16)"`
+	KodePos      string    `json:"kode_pos" gorm:"type:varchar(10)"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
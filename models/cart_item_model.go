@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// CartItem adalah satu produk di keranjang belanja user. Setiap produk (atau varian produk)
+// hanya muncul sekali per user; IDVarian bernilai 0 untuk produk tanpa varian.
+type CartItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDUser    uint      `json:"id_user" gorm:"uniqueIndex:idx_cart_user_produk_varian"`
+	IDProduk  uint      `json:"id_produk" gorm:"uniqueIndex:idx_cart_user_produk_varian"`
+	IDVarian  uint      `json:"id_varian" gorm:"uniqueIndex:idx_cart_user_produk_varian"`
+	Kuantitas int       `json:"kuantitas"`
+	HargaJual int       `json:"harga_jual"` // hanya untuk checkout dropship
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Produk    *Produk   `json:"produk,omitempty" gorm:"foreignKey:IDProduk"`
+}
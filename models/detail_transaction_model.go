@@ -4,14 +4,25 @@ import (
 	"time"
 )
 
+// DetailTransaction adalah satu baris produk pada transaksi. Refund yang disetujui mengurangi
+// HargaTotal dan Margin secara langsung dan dicatat di KuantitasRefund, sehingga laporan selalu
+// memakai angka bersih. Diskon adalah bagian potongan voucher sub-transaksi yang jatuh ke baris ini.
 type DetailTransaction struct {
-	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	IDTrx       uint      `json:"id_trx"`
-	IDLogProduk uint      `json:"id_log_produk"`
-	IDToko      uint      `json:"id_toko"`
-	Kuantitas   int       `json:"kuantitas"`
-	HargaTotal  int       `json:"harga_total"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	LogProduct  LogProduk `json:"log_product,omitempty" gorm:"foreignKey:IDLogProduk"`
+	ID              uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDTrx           uint      `json:"id_trx"`
+	IDSubTrx        uint      `json:"id_sub_trx" gorm:"index"`
+	IDLogProduk     uint      `json:"id_log_produk"`
+	IDVarian        *uint     `json:"id_varian"`
+	IDToko          uint      `json:"id_toko"`
+	Kuantitas       int       `json:"kuantitas"`
+	TierHarga       string    `json:"tier_harga" gorm:"type:varchar(16);default:'konsumen'"`
+	HargaSatuan     int       `json:"harga_satuan"`
+	HargaTotal      int       `json:"harga_total"`
+	Diskon          int       `json:"diskon"`
+	HargaJual       int       `json:"harga_jual"`
+	Margin          int       `json:"margin"`
+	KuantitasRefund int       `json:"kuantitas_refund"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	LogProduct      LogProduk `json:"log_product,omitempty" gorm:"foreignKey:IDLogProduk"`
 }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey menyimpan hasil request yang dikirim dengan header Idempotency-Key
+// sehingga retry dari client dapat dijawab ulang tanpa memproses request dua kali
+type IdempotencyKey struct {
+	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDUser       uint      `json:"id_user" gorm:"uniqueIndex:idx_idempotency_user_key"`
+	Endpoint     string    `json:"endpoint" gorm:"type:varchar(191);uniqueIndex:idx_idempotency_user_key"`
+	Kunci        string    `json:"kunci" gorm:"type:varchar(191);uniqueIndex:idx_idempotency_user_key"`
+	RequestHash  string    `json:"request_hash" gorm:"type:varchar(64)"`
+	StatusCode   int       `json:"status_code"`
+	ResponseBody string    `json:"response_body" gorm:"type:longtext"`
+	Selesai      bool      `json:"selesai" gorm:"default:false"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Jenis pergerakan stok pada ledger inventaris
+const (
+	MovementSale       = "sale"
+	MovementCancel     = "cancel"
+	MovementRefund     = "refund"
+	MovementAdjustment = "adjustment"
+	MovementImport     = "import"
+)
+
+// InventoryMovement adalah satu baris ledger stok yang hanya boleh ditambah, tidak diubah atau
+// dihapus. Kuantitas bertanda: positif menambah stok, negatif mengurangi stok. Jumlah seluruh
+// kuantitas sebuah produk (atau varian) sama dengan stoknya saat ini.
+type InventoryMovement struct {
+	ID          uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDProduk    uint      `json:"id_produk" gorm:"index"`
+	IDVarian    *uint     `json:"id_varian" gorm:"index"`
+	Tipe        string    `json:"tipe" gorm:"type:varchar(16);index"`
+	Kuantitas   int       `json:"kuantitas"`
+	StokSesudah int       `json:"stok_sesudah"`
+	Alasan      string    `json:"alasan"`
+	Aktor       string    `json:"aktor" gorm:"type:varchar(16)"`
+	IDUser      *uint     `json:"id_user"`
+	IDTrx       *uint     `json:"id_trx" gorm:"index"`
+	Referensi   string    `json:"referensi" gorm:"type:varchar(64)"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// InvoiceSequence menyimpan nomor urut invoice terakhir per prefix, tanggal dan kode toko
+type InvoiceSequence struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Prefix    string    `json:"prefix" gorm:"type:varchar(32);uniqueIndex:idx_invoice_sequence"`
+	Tanggal   string    `json:"tanggal" gorm:"type:varchar(16);uniqueIndex:idx_invoice_sequence"`
+	KodeToko  string    `json:"kode_toko" gorm:"type:varchar(32);uniqueIndex:idx_invoice_sequence"`
+	Nomor     int       `json:"nomor"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
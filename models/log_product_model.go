@@ -2,11 +2,19 @@ package models
 
 import "time"
 
+// LogProduk adalah snapshot produk yang dirujuk detail transaksi. Setiap perubahan harga atau
+// data deskriptif produk menghasilkan versi baru sehingga pesanan lama tetap menampilkan data
+// saat dibeli. Nomor versi berurutan per produk (termasuk snapshot variannya) dan unik, sehingga
+// penyimpanan paralel tidak menghasilkan versi ganda.
 type LogProduk struct {
 	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	IDProduk      uint      `json:"id_produk"`
+	IDProduk      uint      `json:"id_produk" gorm:"uniqueIndex:idx_log_produk_versi"`
+	IDVarian      *uint     `json:"id_varian"`
+	Versi         int       `json:"versi" gorm:"default:1;uniqueIndex:idx_log_produk_versi"`
 	NamaProduk    string    `json:"nama_produk"`
 	Slug          string    `json:"slug"`
+	SKU           string    `json:"sku"`
+	NamaVarian    string    `json:"nama_varian"`
 	HargaReseller int       `json:"harga_reseller"`
 	HargaKonsumen int       `json:"harga_konsumen"`
 	Deskripsi     string    `json:"deskripsi"`
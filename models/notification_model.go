@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Jenis notifikasi in-app
+const (
+	NotifikasiStokMenipis      = "stok_menipis"
+	NotifikasiStokHabis        = "stok_habis"
+	NotifikasiDigestStokHarian = "digest_stok_harian"
+)
+
+// Status pengiriman notifikasi melalui notifier eksternal
+const (
+	NotifikasiKirimPending = "pending"
+	NotifikasiKirimSent    = "sent"
+	NotifikasiKirimFailed  = "failed"
+	NotifikasiKirimSkipped = "skipped" // hanya ditampilkan di aplikasi
+)
+
+// Notifikasi adalah pemberitahuan untuk user yang ditampilkan di aplikasi dan, jika notifier
+// dikonfigurasi, dikirim juga ke luar aplikasi oleh scheduler notifikasi
+type Notifikasi struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDUser      uint       `json:"id_user" gorm:"index"`
+	IDToko      *uint      `json:"id_toko" gorm:"index"`
+	Tipe        string     `json:"tipe" gorm:"type:varchar(32)"`
+	Judul       string     `json:"judul"`
+	Pesan       string     `json:"pesan" gorm:"type:text"`
+	IDProduk    *uint      `json:"id_produk"`
+	IDVarian    *uint      `json:"id_varian"`
+	DibacaPada  *time.Time `json:"dibaca_pada"`
+	StatusKirim string     `json:"status_kirim" gorm:"type:varchar(16);default:'pending';index"`
+	Percobaan   int        `json:"-"`
+	DikirimPada *time.Time `json:"dikirim_pada"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Status pembayaran
+const (
+	PaymentStatusPending = "pending"
+	PaymentStatusPaid    = "paid"
+	PaymentStatusFailed  = "failed"
+	PaymentStatusExpired = "expired"
+)
+
+// Payment adalah tagihan pembayaran untuk sebuah transaksi pada penyedia pembayaran tertentu
+type Payment struct {
+	ID             uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDTrx          uint       `json:"id_trx" gorm:"index"`
+	Provider       string     `json:"provider" gorm:"type:varchar(32)"`
+	Referensi      string     `json:"referensi" gorm:"type:varchar(64);uniqueIndex"`
+	Jumlah         int        `json:"jumlah"`
+	Status         string     `json:"status" gorm:"type:varchar(16);default:'pending';index"`
+	NomorVA        string     `json:"nomor_va,omitempty" gorm:"type:varchar(32)"`
+	Instruksi      string     `json:"instruksi,omitempty"`
+	KadaluarsaPada *time.Time `json:"kadaluarsa_pada"`
+	DibayarPada    *time.Time `json:"dibayar_pada"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Status pemrosesan notifikasi pembayaran
+const (
+	NotificationStatusReceived  = "received"
+	NotificationStatusProcessed = "processed"
+	NotificationStatusDuplicate = "duplicate"
+	NotificationStatusRejected  = "rejected"
+	NotificationStatusFailed    = "failed"
+)
+
+// PaymentNotification mencatat setiap payload mentah yang dikirim penyedia pembayaran ke webhook
+// untuk keperluan rekonsiliasi. KunciDedup hanya diisi untuk notifikasi yang berhasil diproses
+// sehingga notifikasi yang sama tidak diproses dua kali.
+type PaymentNotification struct {
+	ID             uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Provider       string    `json:"provider" gorm:"type:varchar(32);index"`
+	IDNotifikasi   string    `json:"id_notifikasi" gorm:"type:varchar(128);index"`
+	KunciDedup     *string   `json:"-" gorm:"type:varchar(191);uniqueIndex"`
+	IDPayment      *uint     `json:"id_payment" gorm:"index"`
+	Referensi      string    `json:"referensi" gorm:"type:varchar(64);index"`
+	StatusPayload  string    `json:"status_payload" gorm:"type:varchar(16)"`
+	Payload        string    `json:"payload" gorm:"type:longtext"`
+	Signature      string    `json:"signature" gorm:"type:varchar(255)"`
+	SignatureValid bool      `json:"signature_valid"`
+	Status         string    `json:"status" gorm:"type:varchar(16);default:'received';index"`
+	Pesan          string    `json:"pesan"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
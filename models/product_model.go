@@ -1,18 +1,32 @@
 package models
 
-import "time"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// Produk adalah barang yang dijual toko. Untuk produk bervarian, Stok adalah jumlah stok
+// seluruh varian dan harga menjadi harga default varian. Penjual diberi peringatan saat stok
+// produk (atau salah satu variannya) turun sampai StokMinimum. Produk yang diarsipkan memakai
+// soft delete: tidak tampil di katalog dan tidak dapat dibeli, tetapi tetap tersimpan untuk
+// riwayat pesanan sampai dihapus permanen oleh purge.
 type Produk struct {
-	ID            uint         `json:"id" gorm:"primaryKey;autoIncrement"`
-	NamaProduk    string       `json:"nama_produk"`
-	Slug          string       `json:"slug"`
-	HargaReseller int          `json:"harga_reseller"`
-	HargaKonsumen int          `json:"harga_konsumen"`
-	Stok          int          `json:"stok"`
-	Deskripsi     string       `json:"deskripsi"`
-	CreatedAt     time.Time    `json:"created_at"`
-	UpdatedAt     time.Time    `json:"updated_at"`
-	IDToko        uint         `json:"id_toko"`
-	IDCategory    uint         `json:"id_category"`
-	FotoProduk    []FotoProduk `json:"foto_produk,omitempty" gorm:"foreignKey:IDProduk"`
+	ID            uint           `json:"id" gorm:"primaryKey;autoIncrement"`
+	NamaProduk    string         `json:"nama_produk"`
+	Slug          string         `json:"slug"`
+	HargaReseller int            `json:"harga_reseller"` // 0: tanpa harga reseller, reseller membayar harga konsumen
+	HargaKonsumen int            `json:"harga_konsumen"`
+	Stok          int            `json:"stok"`
+	StokMinimum   int            `json:"stok_minimum"`
+	Berat         int            `json:"berat"` // gram
+	Deskripsi     string         `json:"deskripsi"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `json:"diarsipkan_pada" gorm:"index"`
+	IDToko        uint           `json:"id_toko"`
+	IDCategory    uint           `json:"id_category"`
+	FotoProduk    []FotoProduk   `json:"foto_produk,omitempty" gorm:"foreignKey:IDProduk"`
+	Opsi          []ProdukOpsi   `json:"opsi,omitempty" gorm:"foreignKey:IDProduk"`
+	Varian        []ProdukVarian `json:"varian,omitempty" gorm:"foreignKey:IDProduk"`
 }
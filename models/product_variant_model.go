@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ProdukOpsi adalah jenis pilihan varian sebuah produk, misalnya Ukuran atau Warna
+type ProdukOpsi struct {
+	ID       uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDProduk uint              `json:"id_produk" gorm:"index"`
+	Nama     string            `json:"nama" gorm:"type:varchar(64)"`
+	Urutan   int               `json:"urutan"`
+	Nilai    []ProdukOpsiNilai `json:"nilai,omitempty" gorm:"foreignKey:IDOpsi"`
+}
+
+// ProdukOpsiNilai adalah satu nilai dari jenis pilihan, misalnya XL atau Merah
+type ProdukOpsiNilai struct {
+	ID     uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDOpsi uint   `json:"id_opsi" gorm:"index"`
+	Nilai  string `json:"nilai" gorm:"type:varchar(64)"`
+	Urutan int    `json:"urutan"`
+}
+
+// ProdukVarian adalah satu kombinasi nilai opsi yang dijual dengan SKU dan stok sendiri.
+// Harga yang kosong mengikuti harga produk induk.
+type ProdukVarian struct {
+	ID            uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDProduk      uint              `json:"id_produk" gorm:"index"`
+	SKU           string            `json:"sku" gorm:"type:varchar(64);uniqueIndex"`
+	Nama          string            `json:"nama"` // gabungan nilai opsi, misalnya "Merah / XL"
+	HargaReseller *int              `json:"harga_reseller"`
+	HargaKonsumen *int              `json:"harga_konsumen"`
+	Stok          int               `json:"stok"`
+	URLFoto       string            `json:"url_foto"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+	Nilai         []ProdukOpsiNilai `json:"nilai,omitempty" gorm:"many2many:produk_varian_nilai"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Status pengajuan refund
+const (
+	RefundStatusRequested = "requested"
+	RefundStatusApproved  = "approved"
+	RefundStatusRejected  = "rejected"
+	RefundStatusRefunded  = "refunded"
+)
+
+// Refund adalah pengajuan pengembalian dana untuk sebagian atau seluruh kuantitas satu
+// detail transaksi. Jumlah dan margin yang dikembalikan dihitung dari harga saat checkout.
+type Refund struct {
+	ID               uint               `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDTrx            uint               `json:"id_trx" gorm:"index"`
+	IDSubTrx         uint               `json:"id_sub_trx" gorm:"index"`
+	IDDetailTrx      uint               `json:"id_detail_trx" gorm:"index"`
+	IDToko           uint               `json:"id_toko" gorm:"index"`
+	IDUser           uint               `json:"id_user" gorm:"index"`
+	Kuantitas        int                `json:"kuantitas"`
+	Jumlah           int                `json:"jumlah"`
+	Margin           int                `json:"margin"`
+	Alasan           string             `json:"alasan"`
+	Restock          bool               `json:"restock"`
+	Status           string             `json:"status" gorm:"type:varchar(16);default:'requested';index"`
+	DiputuskanOleh   *uint              `json:"diputuskan_oleh"`
+	AktorPutusan     string             `json:"aktor_putusan" gorm:"type:varchar(16)"`
+	CatatanPutusan   string             `json:"catatan_putusan"`
+	DiputuskanPada   *time.Time         `json:"diputuskan_pada"`
+	DikembalikanPada *time.Time         `json:"dikembalikan_pada"`
+	CreatedAt        time.Time          `json:"created_at"`
+	UpdatedAt        time.Time          `json:"updated_at"`
+	DetailTrx        *DetailTransaction `json:"detail_transaksi,omitempty" gorm:"foreignKey:IDDetailTrx"`
+}
@@ -5,11 +5,14 @@ import (
 )
 
 type Toko struct {
-	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	IDUser    uint      `json:"id_user"`
-	NamaToko  string    `json:"nama_toko"`
-	URLFoto   string    `json:"url_foto"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Produk    []Produk  `json:"produk,omitempty" gorm:"foreignKey:IDToko"`
-}
\ No newline at end of file
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDUser     uint      `json:"id_user"`
+	NamaToko   string    `json:"nama_toko"`
+	URLFoto    string    `json:"url_foto"`
+	IDProvinsi string    `json:"id_provinsi" gorm:"type:varchar(16)"` // lokasi asal pengiriman
+	IDKota     string    `json:"id_kota" gorm:"type:varchar(16)"`
+	DigestStok bool      `json:"digest_stok"` // peringatan stok dikirim sebagai ringkasan harian
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	Produk     []Produk  `json:"produk,omitempty" gorm:"foreignKey:IDToko"`
+}
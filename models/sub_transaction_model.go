@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// SubTransaction adalah bagian transaksi per toko (fulfilment group) yang diproses dan dikirim
+// secara mandiri oleh masing-masing penjual
+type SubTransaction struct {
+	ID              uint                `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDTrx           uint                `json:"id_trx" gorm:"index"`
+	IDToko          uint                `json:"id_toko" gorm:"index"`
+	KodeInvoice     string              `json:"kode_invoice" gorm:"type:varchar(64);uniqueIndex"`
+	Status          string              `json:"status" gorm:"type:varchar(32);default:'pending_payment';index"`
+	Subtotal        int                 `json:"subtotal"`
+	OngkosKirim     int                 `json:"ongkos_kirim"`
+	Berat           int                 `json:"berat"` // total berat dalam gram
+	Diskon          int                 `json:"diskon"`
+	TotalRefund     int                 `json:"total_refund"`
+	Kurir           string              `json:"kurir" gorm:"type:varchar(32)"`
+	NoResi          string              `json:"no_resi"`
+	DikirimPada     *time.Time          `json:"dikirim_pada"`
+	DilacakPada     *time.Time          `json:"dilacak_pada"` // waktu terakhir status diambil dari kurir
+	CreatedAt       time.Time           `json:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at"`
+	Transaction     *Transaction        `json:"transaksi,omitempty" gorm:"foreignKey:IDTrx"`
+	DetailTransaksi []DetailTransaction `json:"detail_transaksi,omitempty" gorm:"foreignKey:IDSubTrx"`
+	TrackingEvents  []TrackingEvent     `json:"tracking_events,omitempty" gorm:"foreignKey:IDSubTrx"`
+}
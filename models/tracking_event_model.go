@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Status kejadian pada riwayat pengiriman
+const (
+	TrackingStatusShipped        = "shipped"
+	TrackingStatusPickedUp       = "picked_up"
+	TrackingStatusInTransit      = "in_transit"
+	TrackingStatusOutForDelivery = "out_for_delivery"
+	TrackingStatusDelivered      = "delivered"
+	TrackingStatusException      = "exception"
+)
+
+// TrackingEvent adalah satu kejadian pada riwayat pengiriman sub-transaksi, dicatat saat penjual
+// mengirim paket atau saat status diambil dari kurir. Kombinasi sub-transaksi, status dan waktu
+// kejadian unik sehingga polling berulang tidak mencatat kejadian yang sama dua kali.
+type TrackingEvent struct {
+	ID            uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDSubTrx      uint      `json:"id_sub_trx" gorm:"uniqueIndex:idx_tracking_event"`
+	Status        string    `json:"status" gorm:"type:varchar(32);uniqueIndex:idx_tracking_event"`
+	WaktuKejadian time.Time `json:"waktu_kejadian" gorm:"uniqueIndex:idx_tracking_event"`
+	Deskripsi     string    `json:"deskripsi"`
+	Lokasi        string    `json:"lokasi"`
+	Sumber        string    `json:"sumber" gorm:"type:varchar(32)"` // seller atau nama penyedia kurir
+	CreatedAt     time.Time `json:"created_at"`
+}
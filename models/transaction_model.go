@@ -2,15 +2,42 @@ package models
 
 import "time"
 
+// Status siklus hidup transaksi
+const (
+	TrxStatusPendingPayment = "pending_payment"
+	TrxStatusPaid           = "paid"
+	TrxStatusProcessing     = "processing"
+	TrxStatusShipped        = "shipped"
+	TrxStatusDelivered      = "delivered"
+	TrxStatusCompleted      = "completed"
+	TrxStatusCancelled      = "cancelled"
+	TrxStatusExpired        = "expired"
+)
+
 type Transaction struct {
-	ID               uint                `json:"id" gorm:"primaryKey;autoIncrement"`
-	IDUser           uint                `json:"id_user"`
-	AlamatPengiriman uint                `json:"-"`
-	Alamat           Alamat              `json:"alamat_kirim" gorm:"foreignKey:AlamatPengiriman"`
-	HargaTotal       int                 `json:"harga_total"`
-	KodeInvoice      string              `json:"kode_invoice"`
-	MethodBayar      string              `json:"method_bayar"`
-	CreatedAt        time.Time           `json:"created_at"`
-	UpdatedAt        time.Time           `json:"updated_at"`
-	DetailTransaksi  []DetailTransaction `json:"detail_transaksi,omitempty" gorm:"foreignKey:IDTrx"`
+	ID               uint                       `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDUser           uint                       `json:"id_user"`
+	AlamatPengiriman uint                       `json:"-"`
+	Alamat           Alamat                     `json:"alamat_kirim" gorm:"foreignKey:AlamatPengiriman"`
+	HargaTotal       int                        `json:"harga_total"`
+	TotalRefund      int                        `json:"total_refund"`
+	IDVoucher        *uint                      `json:"id_voucher,omitempty"`
+	KodeVoucher      string                     `json:"kode_voucher,omitempty" gorm:"type:varchar(32)"`
+	Diskon           int                        `json:"diskon"`
+	KodeInvoice      string                     `json:"kode_invoice" gorm:"type:varchar(64);uniqueIndex"`
+	MethodBayar      string                     `json:"method_bayar"`
+	Status           string                     `json:"status" gorm:"type:varchar(32);default:'pending_payment';index"`
+	IsDropship       bool                       `json:"is_dropship" gorm:"default:false"`
+	DropshipNama     string                     `json:"dropship_nama,omitempty"`
+	DropshipNoTelp   string                     `json:"dropship_no_telp,omitempty"`
+	DropshipAlamat   string                     `json:"dropship_alamat,omitempty"`
+	AlasanBatal      string                     `json:"alasan_batal,omitempty"`
+	DibatalkanPada   *time.Time                 `json:"dibatalkan_pada,omitempty"`
+	BatasBayar       *time.Time                 `json:"batas_bayar,omitempty" gorm:"index"` // kosong untuk transaksi lama yang tidak dikadaluarsakan
+	CreatedAt        time.Time                  `json:"created_at"`
+	UpdatedAt        time.Time                  `json:"updated_at"`
+	DetailTransaksi  []DetailTransaction        `json:"detail_transaksi,omitempty" gorm:"foreignKey:IDTrx"`
+	SubTransaksi     []SubTransaction           `json:"sub_transaksi,omitempty" gorm:"foreignKey:IDTrx"`
+	RiwayatStatus    []TransactionStatusHistory `json:"riwayat_status,omitempty" gorm:"foreignKey:IDTrx"`
+	Payments         []Payment                  `json:"payments,omitempty" gorm:"foreignKey:IDTrx"`
 }
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// TransactionStatusHistory mencatat setiap perpindahan status transaksi
+type TransactionStatusHistory struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDTrx      uint      `json:"id_trx" gorm:"index"`
+	IDSubTrx   *uint     `json:"id_sub_trx"`
+	StatusDari string    `json:"status_dari" gorm:"type:varchar(32)"`
+	StatusKe   string    `json:"status_ke" gorm:"type:varchar(32)"`
+	IDUser     *uint     `json:"id_user"`
+	Aktor      string    `json:"aktor" gorm:"type:varchar(16)"`
+	Catatan    string    `json:"catatan"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -4,21 +4,35 @@ import (
 	"time"
 )
 
+// Status pengajuan user sebagai reseller
+const (
+	ResellerStatusPending  = "pending"
+	ResellerStatusVerified = "verified"
+	ResellerStatusRejected = "rejected"
+)
+
 type User struct {
-	ID           uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	Nama         string    `json:"nama"`
-	KataSandi    string    `json:"kata_sandi"`
-	NoTelp       string    `json:"no_telp" gorm:"unique"`
-	TanggalLahir string    `json:"tanggal_lahir"`
-	JenisKelamin string    `json:"jenis_kelamin"`
-	Tentang      string    `json:"tentang"`
-	Pekerjaan    string    `json:"pekerjaan"`
-	Email        string    `json:"email"`
-	IDProvinsi   string    `json:"id_provinsi"`
-	IDKota       string    `json:"id_kota"`
-	IsAdmin      bool      `json:"is_admin" gorm:"default:false"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Toko         *Toko     `json:"toko,omitempty" gorm:"foreignKey:IDUser"`
-	Alamat       *[]Alamat `json:"alamat,omitempty" gorm:"foreignKey:IDUser"`
+	ID             uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Nama           string    `json:"nama"`
+	KataSandi      string    `json:"kata_sandi"`
+	NoTelp         string    `json:"no_telp" gorm:"unique"`
+	TanggalLahir   string    `json:"tanggal_lahir"`
+	JenisKelamin   string    `json:"jenis_kelamin"`
+	Tentang        string    `json:"tentang"`
+	Pekerjaan      string    `json:"pekerjaan"`
+	Email          string    `json:"email"`
+	IDProvinsi     string    `json:"id_provinsi"`
+	IDKota         string    `json:"id_kota"`
+	IsAdmin        bool      `json:"is_admin" gorm:"default:false"`
+	IsReseller     bool      `json:"is_reseller" gorm:"default:false"`
+	StatusReseller string    `json:"status_reseller" gorm:"type:varchar(16)"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Toko           *Toko     `json:"toko,omitempty" gorm:"foreignKey:IDUser"`
+	Alamat         *[]Alamat `json:"alamat,omitempty" gorm:"foreignKey:IDUser"`
+}
+
+// IsVerifiedReseller mengecek apakah user adalah reseller yang sudah diverifikasi
+func (u *User) IsVerifiedReseller() bool {
+	return u.IsReseller && u.StatusReseller == ResellerStatusVerified
 }
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Tipe potongan voucher
+const (
+	VoucherTipePersen  = "percentage"
+	VoucherTipeNominal = "fixed"
+)
+
+// Voucher adalah kode promo yang memberi potongan harga saat checkout. Voucher tanpa IDToko
+// berlaku di semua toko, dan voucher dengan Categories hanya memotong produk di kategori tersebut.
+// Nilai 0 pada KuotaTotal, KuotaPerUser dan MaksDiskon berarti tidak dibatasi.
+type Voucher struct {
+	ID           uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Kode         string     `json:"kode" gorm:"type:varchar(32);uniqueIndex"`
+	Nama         string     `json:"nama"`
+	Tipe         string     `json:"tipe" gorm:"type:varchar(16)"`
+	Nilai        int        `json:"nilai"` // persen untuk tipe percentage, rupiah untuk tipe fixed
+	MaksDiskon   int        `json:"maks_diskon"`
+	MinBelanja   int        `json:"min_belanja"`
+	KuotaTotal   int        `json:"kuota_total"`
+	KuotaPerUser int        `json:"kuota_per_user"`
+	Terpakai     int        `json:"terpakai"`
+	MulaiPada    *time.Time `json:"mulai_pada"`
+	BerakhirPada *time.Time `json:"berakhir_pada"`
+	IDToko       *uint      `json:"id_toko" gorm:"index"`
+	Aktif        bool       `json:"aktif"`
+	Categories   []Category `json:"categories,omitempty" gorm:"many2many:voucher_categories"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// VoucherUsage mencatat pemakaian voucher pada sebuah transaksi
+type VoucherUsage struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	IDVoucher uint      `json:"id_voucher" gorm:"index:idx_voucher_usage_user"`
+	IDUser    uint      `json:"id_user" gorm:"index:idx_voucher_usage_user"`
+	IDTrx     uint      `json:"id_trx" gorm:"uniqueIndex"`
+	Diskon    int       `json:"diskon"`
+	CreatedAt time.Time `json:"created_at"`
+}
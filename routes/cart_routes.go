@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/controllers"
+	"github.com/habbazettt/evermos-service-go/middleware"
+)
+
+func CartRoutes(app *fiber.App) {
+	cart := app.Group("/api/v1/cart", middleware.JWTMiddleware())
+
+	cart.Get("/", controllers.GetCart)
+	cart.Post("/items", controllers.AddCartItem)
+	cart.Put("/items/:id", controllers.UpdateCartItem)
+	cart.Delete("/items/:id", controllers.RemoveCartItem)
+	cart.Post("/checkout", middleware.IdempotencyMiddleware(), controllers.CheckoutCart)
+}
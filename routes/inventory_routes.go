@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/controllers"
+	"github.com/habbazettt/evermos-service-go/middleware"
+)
+
+func InventoryRoutes(app *fiber.App) {
+	inventory := app.Group("/api/v1/inventory", middleware.JWTMiddleware())
+
+	inventory.Get("/low-stock", controllers.GetLowStockItems)
+	inventory.Get("/reconciliation", controllers.ReconcileInventory)
+}
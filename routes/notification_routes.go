@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/controllers"
+	"github.com/habbazettt/evermos-service-go/middleware"
+)
+
+func NotificationRoutes(app *fiber.App) {
+	notification := app.Group("/api/v1/notifications", middleware.JWTMiddleware())
+
+	notification.Get("/", controllers.GetNotifications)
+	notification.Put("/read-all", controllers.MarkAllNotificationsRead)
+	notification.Put("/:id/read", controllers.MarkNotificationRead)
+}
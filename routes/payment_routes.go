@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/controllers"
+	"github.com/habbazettt/evermos-service-go/middleware"
+)
+
+func PaymentRoutes(app *fiber.App) {
+	payment := app.Group("/api/v1/payments")
+
+	payment.Post("/webhook/:provider", controllers.PaymentWebhook)
+	payment.Post("/mock/:referensi/settle", middleware.JWTMiddleware(), controllers.SettleMockPayment)
+}
@@ -10,8 +10,22 @@ func ProductRoutes(app *fiber.App) {
 	product := app.Group("/api/v1/product", middleware.JWTMiddleware())
 
 	product.Get("/", controllers.GetAllProducts)
+	product.Get("/export", controllers.ExportProducts)
+	product.Get("/archived", controllers.GetArchivedProducts)
 	product.Get("/:id", controllers.GetProductByID)
-	product.Post("/", controllers.CreateProduct)
+	product.Post("/", middleware.IdempotencyMiddleware(), controllers.CreateProduct)
+	product.Post("/import", middleware.IdempotencyMiddleware(), controllers.ImportProducts)
 	product.Put("/:id", controllers.UpdateProduct)
 	product.Delete("/:id", controllers.DeleteProduct)
+	product.Put("/:id/archive", controllers.ArchiveProduct)
+	product.Put("/:id/unarchive", controllers.UnarchiveProduct)
+	product.Post("/purge", middleware.AdminMiddleware(), controllers.PurgeArchivedProducts)
+
+	product.Get("/:id/variants", controllers.GetProductVariants)
+	product.Put("/:id/variants", controllers.ReplaceProductVariants)
+	product.Put("/:id/variants/:variantId", controllers.UpdateProductVariant)
+	product.Delete("/:id/variants/:variantId", controllers.DeleteProductVariant)
+
+	product.Post("/:id/stock-adjustments", middleware.IdempotencyMiddleware(), controllers.AdjustProductStock)
+	product.Get("/:id/stock-movements", controllers.GetProductStockMovements)
 }
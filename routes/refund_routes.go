@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/controllers"
+	"github.com/habbazettt/evermos-service-go/middleware"
+)
+
+func RefundRoutes(app *fiber.App) {
+	refund := app.Group("/api/v1/refunds", middleware.JWTMiddleware())
+
+	refund.Get("/", controllers.GetRefunds)
+	refund.Put("/:id/approve", controllers.ApproveRefund)
+	refund.Put("/:id/reject", controllers.RejectRefund)
+	refund.Put("/:id/complete", controllers.CompleteRefund)
+}
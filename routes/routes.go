@@ -11,4 +11,10 @@ func SetupRoutes(app *fiber.App) {
 	CategoryRoutes(app)
 	ProductRoutes(app)
 	TransactionRoutes(app)
+	CartRoutes(app)
+	PaymentRoutes(app)
+	RefundRoutes(app)
+	VoucherRoutes(app)
+	InventoryRoutes(app)
+	NotificationRoutes(app)
 }
@@ -11,6 +11,9 @@ func TokoRoutes(app *fiber.App) {
 
 	toko.Get("/", controllers.GetAllStores)
 	toko.Get("/my", controllers.GetMyStore)
+	toko.Get("/my/orders", controllers.GetMyStoreOrders)
+	toko.Get("/my/suborders", controllers.GetMyStoreSubTransactions)
+	toko.Get("/my/suborders/:id", controllers.GetMyStoreSubTransactionByID)
 	toko.Get("/:id", controllers.GetStoreByID)
 	toko.Put("/:id", controllers.UpdateStore)
 }
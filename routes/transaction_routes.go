@@ -11,5 +11,20 @@ func TransactionRoutes(app *fiber.App) {
 
 	transaction.Get("/", controllers.GetAllTransactions)
 	transaction.Get("/:id", controllers.GetTransactionByID)
-	transaction.Post("/", controllers.CreateTransaction)
+	transaction.Post("/", middleware.IdempotencyMiddleware(), controllers.CreateTransaction)
+	transaction.Post("/quote", controllers.QuoteTransaction)
+
+	transaction.Post("/:id/payment", controllers.CreatePayment)
+	transaction.Get("/:id/payment", controllers.GetPayment)
+	transaction.Post("/:id/refunds", controllers.CreateRefund)
+	transaction.Get("/:id/refunds", controllers.GetTransactionRefunds)
+
+	transaction.Get("/:id/history", controllers.GetTransactionStatusHistory)
+	transaction.Get("/:id/tracking", controllers.GetTransactionTracking)
+	transaction.Put("/:id/pay", controllers.PayTransaction)
+	transaction.Put("/:id/process", controllers.ProcessTransaction)
+	transaction.Put("/:id/ship", controllers.ShipTransaction)
+	transaction.Put("/:id/deliver", controllers.DeliverTransaction)
+	transaction.Put("/:id/complete", controllers.CompleteTransaction)
+	transaction.Put("/:id/cancel", controllers.CancelTransaction)
 }
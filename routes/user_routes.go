@@ -12,4 +12,7 @@ func UserRoutes(app *fiber.App) {
 
 	user.Get("/", controllers.GetMyProfile)
 	user.Put("/", controllers.UpdateProfile)
+	user.Post("/reseller", controllers.ApplyReseller)
+	user.Get("/reseller/margin", controllers.GetResellerMarginReport)
+	user.Put("/:id/reseller", middleware.AdminMiddleware(), controllers.VerifyReseller)
 }
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/habbazettt/evermos-service-go/controllers"
+	"github.com/habbazettt/evermos-service-go/middleware"
+)
+
+func VoucherRoutes(app *fiber.App) {
+	voucher := app.Group("/api/v1/vouchers", middleware.JWTMiddleware())
+
+	voucher.Get("/", controllers.GetVouchers)
+	voucher.Post("/", controllers.CreateVoucher)
+	voucher.Put("/:id", controllers.UpdateVoucher)
+	voucher.Delete("/:id", controllers.DeleteVoucher)
+}
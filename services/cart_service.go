@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrItemKeranjangTidakDitemukan = errors.New("item keranjang tidak ditemukan")
+	ErrKeranjangKosong             = errors.New("keranjang belanja kosong")
+	ErrKeranjangBerubah            = errors.New("keranjang berubah selama checkout, silakan coba lagi")
+)
+
+// CartLine adalah item keranjang yang sudah divalidasi terhadap harga dan stok produk saat ini
+type CartLine struct {
+	ID          uint   `json:"id"`
+	IDProduk    uint   `json:"id_produk"`
+	IDVarian    uint   `json:"id_varian,omitempty"`
+	NamaProduk  string `json:"nama_produk"`
+	NamaVarian  string `json:"nama_varian,omitempty"`
+	SKU         string `json:"sku,omitempty"`
+	Slug        string `json:"slug"`
+	Kuantitas   int    `json:"kuantitas"`
+	Stok        int    `json:"stok"`
+	TierHarga   string `json:"tier_harga"`
+	HargaSatuan int    `json:"harga_satuan"`
+	HargaJual   int    `json:"harga_jual"`
+	Subtotal    int    `json:"subtotal"`
+	Tersedia    bool   `json:"tersedia"`
+	Pesan       string `json:"pesan,omitempty"`
+}
+
+// CartStore adalah item keranjang dari satu toko
+type CartStore struct {
+	IDToko   uint       `json:"id_toko"`
+	NamaToko string     `json:"nama_toko"`
+	Items    []CartLine `json:"items"`
+	Subtotal int        `json:"subtotal"`
+}
+
+// Cart adalah isi keranjang user yang dikelompokkan per toko
+type Cart struct {
+	Toko       []CartStore `json:"toko"`
+	TotalItem  int         `json:"total_item"`
+	TotalHarga int         `json:"total_harga"`
+	Valid      bool        `json:"valid"` // false jika ada item yang tidak dapat dibeli
+}
+
+// CartCheckoutRequest adalah data checkout keranjang. Item diambil dari keranjang user.
+type CartCheckoutRequest struct {
+	MethodBayar      string       `json:"method_bayar"`
+	AlamatPengiriman uint         `json:"alamat_kirim"`
+	IsDropship       bool         `json:"is_dropship"`
+	Dropship         DropshipInfo `json:"dropship"`
+	KodeVoucher      string       `json:"kode_voucher"`
+}
+
+// validateCartQuantity memastikan kuantitas valid dan tidak melebihi stok produk (atau varian) saat ini
+func validateCartQuantity(produk *models.Produk, qty int) error {
+	if qty <= 0 {
+		return ErrKuantitasTidakValid
+	}
+	if qty > produk.Stok {
+		return ErrStokTidakMencukupi
+	}
+	return nil
+}
+
+// GetCart mengambil isi keranjang user dengan harga dan stok terbaru, dikelompokkan per toko
+func GetCart(userID uint) (*Cart, error) {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+	tier := PriceTierForUser(&user)
+
+	var items []models.CartItem
+	if err := config.DB.Preload("Produk").Where("id_user = ?", userID).
+		Order("id ASC").Find(&items).Error; err != nil {
+		return nil, errors.New("gagal mengambil keranjang")
+	}
+
+	cart := &Cart{Toko: []CartStore{}, Valid: true}
+	stores := map[uint]*CartStore{}
+	var tokoIDs []uint
+
+	for _, item := range items {
+		line := CartLine{
+			ID:        item.ID,
+			IDProduk:  item.IDProduk,
+			IDVarian:  item.IDVarian,
+			Kuantitas: item.Kuantitas,
+			HargaJual: item.HargaJual,
+			Tersedia:  true,
+		}
+
+		var tokoID uint
+		if item.Produk == nil {
+			line.Tersedia = false
+			line.Pesan = ErrProdukTidakDitemukan.Error()
+		} else {
+			tokoID = item.Produk.IDToko
+			line.NamaProduk = item.Produk.NamaProduk
+			line.Slug = item.Produk.Slug
+
+			// Varian yang dihapus penjual membuat item tidak dapat dibeli
+			varian, err := resolveVariant(config.DB, item.IDProduk, item.IDVarian, false)
+			if err != nil {
+				line.Tersedia = false
+				line.Pesan = err.Error()
+			} else {
+				produk := applyVariant(item.Produk, varian)
+				if varian != nil {
+					line.NamaVarian = varian.Nama
+					line.SKU = varian.SKU
+				}
+				line.Stok = produk.Stok
+				line.HargaSatuan, line.TierHarga = UnitPrice(&produk, tier)
+				line.Subtotal = line.HargaSatuan * item.Kuantitas
+				if err := validateCartQuantity(&produk, item.Kuantitas); err != nil {
+					line.Tersedia = false
+					line.Pesan = err.Error()
+				}
+			}
+		}
+
+		store, ok := stores[tokoID]
+		if !ok {
+			store = &CartStore{IDToko: tokoID, Items: []CartLine{}}
+			stores[tokoID] = store
+			tokoIDs = append(tokoIDs, tokoID)
+		}
+		store.Items = append(store.Items, line)
+
+		if !line.Tersedia {
+			cart.Valid = false
+			continue
+		}
+		store.Subtotal += line.Subtotal
+		cart.TotalItem += line.Kuantitas
+		cart.TotalHarga += line.Subtotal
+	}
+
+	// Lengkapi nama toko
+	var tokos []models.Toko
+	if len(tokoIDs) > 0 {
+		config.DB.Where("id IN ?", tokoIDs).Find(&tokos)
+	}
+	for _, toko := range tokos {
+		if store, ok := stores[toko.ID]; ok {
+			store.NamaToko = toko.NamaToko
+		}
+	}
+
+	sort.Slice(tokoIDs, func(i, j int) bool { return tokoIDs[i] < tokoIDs[j] })
+	for _, tokoID := range tokoIDs {
+		cart.Toko = append(cart.Toko, *stores[tokoID])
+	}
+	return cart, nil
+}
+
+// AddCartItem menambahkan produk (atau varian produk) ke keranjang. Jika sudah ada, kuantitasnya dijumlahkan.
+func AddCartItem(userID, produkID, varianID uint, qty, hargaJual int) (*models.CartItem, error) {
+	if qty <= 0 {
+		return nil, ErrKuantitasTidakValid
+	}
+
+	var produk models.Produk
+	if err := config.DB.First(&produk, produkID).Error; err != nil {
+		return nil, ErrProdukTidakDitemukan
+	}
+	varian, err := resolveVariant(config.DB, produkID, varianID, false)
+	if err != nil {
+		return nil, err
+	}
+	effective := applyVariant(&produk, varian)
+
+	var item models.CartItem
+	err = config.DB.Where("id_user = ? AND id_produk = ? AND id_varian = ?", userID, produkID, varianID).First(&item).Error
+	if err != nil {
+		item = models.CartItem{IDUser: userID, IDProduk: produkID, IDVarian: varianID}
+	}
+
+	if err := validateCartQuantity(&effective, item.Kuantitas+qty); err != nil {
+		return nil, err
+	}
+
+	item.Kuantitas += qty
+	if hargaJual > 0 {
+		item.HargaJual = hargaJual
+	}
+	if err := config.DB.Save(&item).Error; err != nil {
+		return nil, errors.New("gagal menyimpan item keranjang")
+	}
+
+	item.Produk = &produk
+	return &item, nil
+}
+
+// UpdateCartItem mengganti kuantitas item keranjang milik user. Harga jual dropship hanya
+// diubah jika diisi.
+func UpdateCartItem(userID, itemID uint, qty int, hargaJual *int) (*models.CartItem, error) {
+	var item models.CartItem
+	if err := config.DB.Preload("Produk").Where("id = ? AND id_user = ?", itemID, userID).
+		First(&item).Error; err != nil {
+		return nil, ErrItemKeranjangTidakDitemukan
+	}
+	if item.Produk == nil {
+		return nil, ErrProdukTidakDitemukan
+	}
+	varian, err := resolveVariant(config.DB, item.IDProduk, item.IDVarian, false)
+	if err != nil {
+		return nil, err
+	}
+
+	effective := applyVariant(item.Produk, varian)
+	if err := validateCartQuantity(&effective, qty); err != nil {
+		return nil, err
+	}
+
+	item.Kuantitas = qty
+	if hargaJual != nil {
+		item.HargaJual = *hargaJual
+	}
+	if err := config.DB.Model(&item).Updates(map[string]interface{}{
+		"kuantitas":  item.Kuantitas,
+		"harga_jual": item.HargaJual,
+	}).Error; err != nil {
+		return nil, errors.New("gagal memperbarui item keranjang")
+	}
+	return &item, nil
+}
+
+// RemoveCartItem menghapus item dari keranjang user
+func RemoveCartItem(userID, itemID uint) error {
+	result := config.DB.Where("id = ? AND id_user = ?", itemID, userID).Delete(&models.CartItem{})
+	if result.Error != nil {
+		return errors.New("gagal menghapus item keranjang")
+	}
+	if result.RowsAffected == 0 {
+		return ErrItemKeranjangTidakDitemukan
+	}
+	return nil
+}
+
+// consumeCartItems mengurangi kuantitas item keranjang sebanyak yang dibeli. Pengurangan bersyarat
+// memastikan kuantitas di keranjang masih mencukupi; jika item dihapus atau dikurangi bersamaan
+// dengan checkout, ErrKeranjangBerubah dikembalikan agar checkout dibatalkan. Item yang habis dihapus,
+// sedangkan kuantitas yang ditambahkan bersamaan tetap tersimpan.
+func consumeCartItems(tx *gorm.DB, userID uint, items []models.CartItem) error {
+	for _, item := range items {
+		result := tx.Model(&models.CartItem{}).
+			Where("id_user = ? AND id_produk = ? AND id_varian = ? AND kuantitas >= ?",
+				userID, item.IDProduk, item.IDVarian, item.Kuantitas).
+			Update("kuantitas", gorm.Expr("kuantitas - ?", item.Kuantitas))
+		if result.Error != nil {
+			return errors.New("gagal mengosongkan keranjang")
+		}
+		if result.RowsAffected == 0 {
+			return ErrKeranjangBerubah
+		}
+	}
+
+	if err := tx.Where("id_user = ? AND kuantitas <= 0", userID).Delete(&models.CartItem{}).Error; err != nil {
+		return errors.New("gagal mengosongkan keranjang")
+	}
+	return nil
+}
+
+// CheckoutCart mengubah isi keranjang menjadi transaksi melalui CreateTransaction. Item yang dibeli
+// dikeluarkan dari keranjang di DB transaction yang sama, sehingga keranjang tidak pernah kosong
+// tanpa transaksi atau sebaliknya. Stok divalidasi ulang saat checkout.
+func CheckoutCart(userID uint, req CartCheckoutRequest) (*models.Transaction, error) {
+	var items []models.CartItem
+	if err := config.DB.Where("id_user = ?", userID).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, errors.New("gagal mengambil keranjang")
+	}
+	if len(items) == 0 {
+		return nil, ErrKeranjangKosong
+	}
+
+	checkoutItems := make([]CheckoutItem, 0, len(items))
+	for _, item := range items {
+		checkoutItems = append(checkoutItems, CheckoutItem{
+			ProductID: item.IDProduk,
+			IDVarian:  item.IDVarian,
+			Kuantitas: item.Kuantitas,
+			HargaJual: item.HargaJual,
+		})
+	}
+
+	return createTransaction(userID, CheckoutRequest{
+		MethodBayar:      req.MethodBayar,
+		AlamatPengiriman: req.AlamatPengiriman,
+		DetailTransaksi:  checkoutItems,
+		IsDropship:       req.IsDropship,
+		Dropship:         req.Dropship,
+		KodeVoucher:      req.KodeVoucher,
+	}, func(tx *gorm.DB) error {
+		return consumeCartItems(tx, userID, items)
+	})
+}
@@ -0,0 +1,355 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAlamatTidakDitemukan  = errors.New("alamat tidak ditemukan")
+	ErrDetailTransaksiKosong = errors.New("detail transaksi tidak boleh kosong")
+	ErrDropshipBukanReseller = errors.New("dropship hanya tersedia untuk reseller terverifikasi")
+	ErrDataDropshipKosong    = errors.New("nama, nomor telepon dan alamat pelanggan dropship wajib diisi")
+	ErrHargaJualTidakValid   = errors.New("harga jual tidak boleh lebih rendah dari harga reseller")
+)
+
+// CheckoutItem adalah satu baris produk yang dibeli
+type CheckoutItem struct {
+	ProductID uint `json:"product_id"`
+	IDVarian  uint `json:"id_varian"` // wajib untuk produk bervarian
+	Kuantitas int  `json:"kuantitas"`
+	HargaJual int  `json:"harga_jual"` // hanya untuk dropship, harga per unit yang dibayar pelanggan akhir
+}
+
+// DropshipInfo berisi data pelanggan akhir reseller pada pesanan dropship
+type DropshipInfo struct {
+	Nama   string `json:"nama"`
+	NoTelp string `json:"no_telp"`
+	Alamat string `json:"alamat"`
+}
+
+// CheckoutRequest adalah data yang dibutuhkan untuk membuat transaksi
+type CheckoutRequest struct {
+	MethodBayar      string         `json:"method_bayar"`
+	AlamatPengiriman uint           `json:"alamat_kirim"`
+	DetailTransaksi  []CheckoutItem `json:"detail_transaksi"`
+	IsDropship       bool           `json:"is_dropship"`
+	Dropship         DropshipInfo   `json:"dropship"`
+	KodeVoucher      string         `json:"kode_voucher"`
+}
+
+// validateDropship memastikan pesanan dropship dibuat oleh reseller terverifikasi dengan data pelanggan lengkap
+func validateDropship(user *models.User, req *CheckoutRequest) error {
+	if !req.IsDropship {
+		return nil
+	}
+	if !user.IsVerifiedReseller() {
+		return ErrDropshipBukanReseller
+	}
+
+	req.Dropship.Nama = strings.TrimSpace(req.Dropship.Nama)
+	req.Dropship.NoTelp = strings.TrimSpace(req.Dropship.NoTelp)
+	req.Dropship.Alamat = strings.TrimSpace(req.Dropship.Alamat)
+	if req.Dropship.Nama == "" || req.Dropship.NoTelp == "" || req.Dropship.Alamat == "" {
+		return ErrDataDropshipKosong
+	}
+	return nil
+}
+
+// checkoutLine adalah baris checkout yang sudah divalidasi dan dihitung harganya
+type checkoutLine struct {
+	Produk      models.Produk
+	Varian      *models.ProdukVarian
+	LogProduk   models.LogProduk
+	Kuantitas   int
+	TierHarga   string
+	HargaSatuan int
+	HargaTotal  int
+	HargaJual   int
+	Margin      int
+}
+
+// varianID mengembalikan ID varian baris, atau nil untuk produk tanpa varian
+func (l *checkoutLine) varianID() *uint {
+	if l.Varian == nil {
+		return nil
+	}
+	return &l.Varian.ID
+}
+
+// priceCheckoutLine menghitung harga satu baris checkout sesuai tier harga pembeli. Dipakai oleh
+// checkout dan quote agar perhitungan harga selalu sama. Harga varian menggantikan harga produk.
+func priceCheckoutLine(produk *models.Produk, varian *models.ProdukVarian, item CheckoutItem, tier string, dropship bool) (checkoutLine, error) {
+	if item.Kuantitas <= 0 {
+		return checkoutLine{}, ErrKuantitasTidakValid
+	}
+
+	effective := applyVariant(produk, varian)
+	hargaSatuan, tierHarga := UnitPrice(&effective, tier)
+	line := checkoutLine{
+		Produk:      *produk,
+		Varian:      varian,
+		Kuantitas:   item.Kuantitas,
+		TierHarga:   tierHarga,
+		HargaSatuan: hargaSatuan,
+		HargaTotal:  hargaSatuan * item.Kuantitas,
+	}
+
+	// Pada dropship, reseller menentukan harga jual ke pelanggan akhir dan mendapat selisihnya
+	if dropship {
+		line.HargaJual = item.HargaJual
+		if line.HargaJual == 0 {
+			line.HargaJual = effective.HargaKonsumen
+		}
+		if line.HargaJual < hargaSatuan {
+			return line, ErrHargaJualTidakValid
+		}
+		line.Margin = (line.HargaJual - hargaSatuan) * item.Kuantitas
+	}
+
+	return line, nil
+}
+
+// reserveCheckoutLines memvalidasi produk, mengurangi stok dan menghitung harga setiap baris.
+// Pengurangan stok dicatat di ledger dengan keterangan change.
+func reserveCheckoutLines(tx *gorm.DB, items []CheckoutItem, tier string, dropship bool, change StockChange) ([]checkoutLine, error) {
+	// Urutkan berdasarkan produk dan varian agar urutan row lock konsisten antar checkout paralel
+	sorted := make([]CheckoutItem, len(items))
+	copy(sorted, items)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ProductID != sorted[j].ProductID {
+			return sorted[i].ProductID < sorted[j].ProductID
+		}
+		return sorted[i].IDVarian < sorted[j].IDVarian
+	})
+
+	lines := make([]checkoutLine, 0, len(sorted))
+	for _, item := range sorted {
+		// Produk dikunci agar harga yang dipakai sama dengan snapshot LogProduk yang dirujuk
+		var produk models.Produk
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&produk, item.ProductID).Error; err != nil {
+			return nil, ErrProdukTidakDitemukan
+		}
+
+		varian, err := resolveVariant(tx, produk.ID, item.IDVarian, true)
+		if err != nil {
+			return nil, err
+		}
+
+		line, err := priceCheckoutLine(&produk, varian, item, tier, dropship)
+		if err != nil {
+			return nil, err
+		}
+
+		// Kurangi stok secara kondisional agar checkout paralel tidak oversell
+		if err := ReserveStock(tx, produk.ID, line.varianID(), item.Kuantitas, change); err != nil {
+			return nil, err
+		}
+
+		line.LogProduk, err = SnapshotProduct(tx, &produk, varian)
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// groupLinesByStore mengelompokkan baris checkout per toko, diurutkan berdasarkan ID toko
+func groupLinesByStore(lines []checkoutLine) ([]uint, map[uint][]checkoutLine) {
+	groups := map[uint][]checkoutLine{}
+	var tokoIDs []uint
+	for _, line := range lines {
+		if _, ok := groups[line.Produk.IDToko]; !ok {
+			tokoIDs = append(tokoIDs, line.Produk.IDToko)
+		}
+		groups[line.Produk.IDToko] = append(groups[line.Produk.IDToko], line)
+	}
+	sort.Slice(tokoIDs, func(i, j int) bool { return tokoIDs[i] < tokoIDs[j] })
+	return tokoIDs, groups
+}
+
+// CreateTransaction membuat transaksi beserta sub-transaksi per toko dan detailnya
+func CreateTransaction(userID uint, req CheckoutRequest) (*models.Transaction, error) {
+	return createTransaction(userID, req, nil)
+}
+
+// createTransaction adalah implementasi CreateTransaction. afterCreate (jika ada) dijalankan di
+// DB transaction yang sama setelah transaksi tersimpan, sehingga kegagalannya ikut membatalkan
+// transaksi beserta reservasi stoknya.
+func createTransaction(userID uint, req CheckoutRequest, afterCreate func(tx *gorm.DB) error) (*models.Transaction, error) {
+	if len(req.DetailTransaksi) == 0 {
+		return nil, ErrDetailTransaksiKosong
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+
+	if err := validateDropship(&user, &req); err != nil {
+		return nil, err
+	}
+
+	// Metode bayar harus sesuai dengan penyedia pembayaran yang tersedia
+	provider, err := GetPaymentProvider(req.MethodBayar)
+	if err != nil {
+		return nil, err
+	}
+	req.MethodBayar = provider.Name()
+
+	// Periksa apakah alamat kirim valid
+	var alamat models.Alamat
+	if err := config.DB.Where("id = ? AND id_user = ?", req.AlamatPengiriman, userID).First(&alamat).Error; err != nil {
+		return nil, ErrAlamatTidakDitemukan
+	}
+
+	shipping, err := GetShippingRateProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	// Generate kode invoice unik
+	produkIDs := make([]uint, 0, len(req.DetailTransaksi))
+	for _, item := range req.DetailTransaksi {
+		produkIDs = append(produkIDs, item.ProductID)
+	}
+	kodeInvoice, err := NextInvoiceNumber(StoreCodeForProducts(config.DB, produkIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	batasBayar := time.Now().Add(LoadOrderExpiryConfig().Deadline)
+	transaction := models.Transaction{
+		IDUser:           userID,
+		AlamatPengiriman: req.AlamatPengiriman,
+		KodeInvoice:      kodeInvoice,
+		MethodBayar:      req.MethodBayar,
+		Status:           models.TrxStatusPendingPayment,
+		IsDropship:       req.IsDropship,
+		DropshipNama:     req.Dropship.Nama,
+		DropshipNoTelp:   req.Dropship.NoTelp,
+		DropshipAlamat:   req.Dropship.Alamat,
+		BatasBayar:       &batasBayar,
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&transaction).Error; err != nil {
+			return errors.New("gagal menyimpan transaksi")
+		}
+
+		// Catat status awal transaksi
+		if err := RecordInitialStatus(tx, &transaction, userID); err != nil {
+			return errors.New("gagal menyimpan riwayat status transaksi")
+		}
+
+		// Reseller terverifikasi membayar harga reseller
+		change := stockChangeForTransaction(&transaction, models.MovementSale, AktorBuyer, &userID, "Checkout")
+		lines, err := reserveCheckoutLines(tx, req.DetailTransaksi, PriceTierForUser(&user), req.IsDropship, change)
+		if err != nil {
+			return err
+		}
+
+		// Voucher dipakai di DB transaction yang sama agar kuotanya ikut batal jika checkout gagal
+		var diskon *VoucherDiscount
+		if strings.TrimSpace(req.KodeVoucher) != "" {
+			diskon, err = redeemVoucher(tx, userID, transaction.ID, req.KodeVoucher, lines)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Buat sub-transaksi untuk setiap toko
+		tokoIDs, groups := groupLinesByStore(lines)
+		var totalHarga int
+		for _, tokoID := range tokoIDs {
+			// Ongkos kirim dihitung per toko dari lokasi toko ke alamat pembeli
+			var toko models.Toko
+			if err := tx.First(&toko, tokoID).Error; err != nil {
+				return errors.New("toko tidak ditemukan")
+			}
+			rate, berat, err := shippingFeeForStore(shipping, &toko, &alamat, groups[tokoID])
+			if err != nil {
+				return err
+			}
+
+			subTrx := models.SubTransaction{
+				IDTrx:       transaction.ID,
+				IDToko:      tokoID,
+				KodeInvoice: transaction.KodeInvoice + "-" + StoreCode(tokoID),
+				Status:      models.TrxStatusPendingPayment,
+				OngkosKirim: rate.Ongkir,
+				Berat:       berat,
+				Diskon:      diskon.ForToko(tokoID),
+			}
+			if err := tx.Create(&subTrx).Error; err != nil {
+				return errors.New("gagal menyimpan sub-transaksi")
+			}
+
+			// Potongan voucher dicatat per baris agar refund mengembalikan nilai yang benar-benar dibayar
+			diskonBaris := diskon.ForLines(tokoID, groups[tokoID])
+			for i, line := range groups[tokoID] {
+				detailTrx := models.DetailTransaction{
+					IDTrx:       transaction.ID,
+					IDSubTrx:    subTrx.ID,
+					IDLogProduk: line.LogProduk.ID,
+					IDVarian:    line.varianID(),
+					IDToko:      tokoID,
+					Kuantitas:   line.Kuantitas,
+					TierHarga:   line.TierHarga,
+					HargaSatuan: line.HargaSatuan,
+					HargaTotal:  line.HargaTotal,
+					Diskon:      diskonBaris[i],
+					HargaJual:   line.HargaJual,
+					Margin:      line.Margin,
+				}
+				if err := tx.Create(&detailTrx).Error; err != nil {
+					return errors.New("gagal menyimpan detail transaksi")
+				}
+				subTrx.Subtotal += line.HargaTotal
+			}
+
+			if err := tx.Model(&subTrx).Update("subtotal", subTrx.Subtotal).Error; err != nil {
+				return errors.New("gagal memperbarui subtotal sub-transaksi")
+			}
+			totalHarga += subTrx.Subtotal + subTrx.OngkosKirim - subTrx.Diskon
+		}
+
+		// Update total harga transaksi beserta voucher yang dipakai
+		updates := map[string]interface{}{"harga_total": totalHarga}
+		if diskon != nil {
+			updates["id_voucher"] = diskon.Voucher.ID
+			updates["kode_voucher"] = diskon.Voucher.Kode
+			updates["diskon"] = diskon.Total
+		}
+		if err := tx.Model(&transaction).Updates(updates).Error; err != nil {
+			return errors.New("gagal memperbarui total harga transaksi")
+		}
+
+		if afterCreate != nil {
+			return afterCreate(tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Ambil transaksi yang baru dibuat
+	var trx models.Transaction
+	if err := config.DB.Preload("DetailTransaksi.LogProduct").Preload("SubTransaksi").Preload("Alamat").
+		First(&trx, transaction.ID).Error; err != nil {
+		return nil, errors.New("gagal mengambil transaksi yang baru dibuat")
+	}
+
+	return &trx, nil
+}
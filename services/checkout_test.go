@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// TestCreateTransactionParallelNoOversell menjalankan checkout paralel yang jumlahnya melebihi
+// stok produk dan memastikan hanya sebanyak stok yang berhasil, tanpa stok negatif
+func TestCreateTransactionParallelNoOversell(t *testing.T) {
+	requireDB(t)
+
+	const stok = 5
+	const pembeli = 20
+	_, produk := createTestProduct(t, stok, 10000)
+
+	var wg sync.WaitGroup
+	errs := make([]error, pembeli)
+	for i := 0; i < pembeli; i++ {
+		user, alamat := createTestUser(t)
+		wg.Add(1)
+		go func(i int, userID, alamatID uint) {
+			defer wg.Done()
+			_, errs[i] = CreateTransaction(userID, CheckoutRequest{
+				MethodBayar:      PaymentProviderBankTransfer,
+				AlamatPengiriman: alamatID,
+				DetailTransaksi:  []CheckoutItem{{ProductID: produk.ID, Kuantitas: 1}},
+			})
+		}(i, user.ID, alamat.ID)
+	}
+	wg.Wait()
+
+	berhasil := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			berhasil++
+		case errors.Is(err, ErrStokTidakMencukupi):
+		default:
+			t.Errorf("checkout gagal dengan error tak terduga: %v", err)
+		}
+	}
+	if berhasil != stok {
+		t.Errorf("checkout berhasil = %d, seharusnya %d", berhasil, stok)
+	}
+
+	var sisa models.Produk
+	if err := config.DB.First(&sisa, produk.ID).Error; err != nil {
+		t.Fatalf("gagal membaca produk: %v", err)
+	}
+	if sisa.Stok != 0 {
+		t.Errorf("stok akhir = %d, seharusnya 0", sisa.Stok)
+	}
+
+	var terjual int64
+	config.DB.Model(&models.DetailTransaction{}).
+		Joins("JOIN log_produks ON log_produks.id = detail_transactions.id_log_produk").
+		Where("log_produks.id_produk = ?", produk.ID).
+		Select("COALESCE(SUM(detail_transactions.kuantitas), 0)").Scan(&terjual)
+	if terjual != stok {
+		t.Errorf("kuantitas terjual = %d, seharusnya %d", terjual, stok)
+	}
+}
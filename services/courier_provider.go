@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// Nama penyedia API pelacakan kurir
+const (
+	CourierProviderStub = "stub"
+)
+
+var (
+	ErrKurirTidakDikenal          = errors.New("kurir tidak dikenal")
+	ErrProviderKurirTidakTersedia = errors.New("penyedia pelacakan kurir belum dikonfigurasi")
+)
+
+// SupportedCouriers adalah kode kurir yang dapat dipilih penjual saat mengirim paket
+var SupportedCouriers = map[string]string{
+	"jne":      "JNE",
+	"jnt":      "J&T Express",
+	"sicepat":  "SiCepat",
+	"anteraja": "AnterAja",
+	"pos":      "Pos Indonesia",
+}
+
+// normalizeCourier menyeragamkan kode kurir dan memastikan kurir didukung
+func normalizeCourier(kurir string) (string, error) {
+	kurir = strings.ToLower(strings.TrimSpace(kurir))
+	if _, ok := SupportedCouriers[kurir]; !ok {
+		return "", ErrKurirTidakDikenal
+	}
+	return kurir, nil
+}
+
+// TrackingUpdate adalah satu kejadian pengiriman yang dilaporkan kurir
+type TrackingUpdate struct {
+	Status    string
+	Deskripsi string
+	Lokasi    string
+	Waktu     time.Time
+}
+
+// CourierProvider adalah kontrak yang harus dipenuhi setiap penyedia API pelacakan kurir
+type CourierProvider interface {
+	// Name mengembalikan nama penyedia, dicatat sebagai sumber kejadian pengiriman
+	Name() string
+	// Track mengambil seluruh kejadian pengiriman untuk paket sub-transaksi
+	Track(sub *models.SubTransaction) ([]TrackingUpdate, error)
+}
+
+var courierProviders = map[string]CourierProvider{
+	CourierProviderStub: &StubCourierProvider{},
+}
+
+// GetCourierProvider mengambil penyedia pelacakan yang dipilih lewat COURIER_PROVIDER. Tanpa
+// konfigurasi, riwayat pengiriman hanya berisi kejadian yang dicatat penjual.
+func GetCourierProvider() (CourierProvider, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("COURIER_PROVIDER")))
+	if name == "" {
+		return nil, ErrProviderKurirTidakTersedia
+	}
+
+	provider, ok := courierProviders[name]
+	if !ok {
+		return nil, ErrProviderKurirTidakTersedia
+	}
+	return provider, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"os"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// stubTrackingSteps adalah urutan kejadian yang disimulasikan StubCourierProvider
+var stubTrackingSteps = []TrackingUpdate{
+	{Status: models.TrackingStatusPickedUp, Deskripsi: "Paket telah diambil oleh kurir", Lokasi: "Gudang asal"},
+	{Status: models.TrackingStatusInTransit, Deskripsi: "Paket dalam perjalanan ke kota tujuan", Lokasi: "Hub transit"},
+	{Status: models.TrackingStatusOutForDelivery, Deskripsi: "Paket sedang diantar ke alamat tujuan", Lokasi: "Gudang tujuan"},
+	{Status: models.TrackingStatusDelivered, Deskripsi: "Paket telah diterima", Lokasi: "Alamat tujuan"},
+}
+
+// StubCourierProvider mensimulasikan API kurir untuk pengembangan lokal. Setiap kejadian muncul
+// satu langkah (COURIER_STUB_STEP, default 6 jam) setelah kejadian sebelumnya, dihitung dari
+// waktu paket dikirim, sehingga hasilnya deterministik untuk nomor resi yang sama.
+type StubCourierProvider struct{}
+
+func (p *StubCourierProvider) Name() string {
+	return CourierProviderStub
+}
+
+func (p *StubCourierProvider) Track(sub *models.SubTransaction) ([]TrackingUpdate, error) {
+	if sub.DikirimPada == nil {
+		return nil, nil
+	}
+
+	step := 6 * time.Hour
+	if v, err := time.ParseDuration(os.Getenv("COURIER_STUB_STEP")); err == nil && v > 0 {
+		step = v
+	}
+
+	var updates []TrackingUpdate
+	now := time.Now()
+	for i, s := range stubTrackingSteps {
+		waktu := sub.DikirimPada.Add(time.Duration(i+1) * step)
+		if waktu.After(now) {
+			break
+		}
+		s.Waktu = waktu
+		updates = append(updates, s)
+	}
+	return updates, nil
+}
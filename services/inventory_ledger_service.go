@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAlasanPenyesuaianKosong = errors.New("alasan penyesuaian stok wajib diisi")
+	ErrPenyesuaianNol          = errors.New("kuantitas penyesuaian tidak boleh 0")
+)
+
+// StockAdjustmentInput adalah penyesuaian stok manual oleh penjual, misalnya hasil stock opname
+// atau barang rusak. Kuantitas positif menambah stok, negatif mengurangi stok.
+type StockAdjustmentInput struct {
+	IDVarian  uint   `json:"id_varian"`
+	Kuantitas int    `json:"kuantitas"`
+	Alasan    string `json:"alasan"`
+}
+
+// StockMovementFilter berisi filter untuk riwayat pergerakan stok produk
+type StockMovementFilter struct {
+	IDVarian uint
+	Tipe     string
+	Page     int
+	Limit    int
+}
+
+// InventoryDrift adalah stok satu produk atau varian dibandingkan dengan jumlah ledger-nya
+type InventoryDrift struct {
+	IDProduk     uint   `json:"id_produk"`
+	IDVarian     *uint  `json:"id_varian"`
+	NamaProduk   string `json:"nama_produk"`
+	SKU          string `json:"sku"`
+	Stok         int    `json:"stok"`
+	StokLedger   int    `json:"stok_ledger"`
+	Selisih      int    `json:"selisih"`
+	JumlahLedger int64  `json:"-"`
+}
+
+// InventoryReconciliation adalah hasil pencocokan stok dengan ledger inventaris
+type InventoryReconciliation struct {
+	DiperiksaPada   time.Time        `json:"diperiksa_pada"`
+	JumlahDiperiksa int              `json:"jumlah_diperiksa"`
+	JumlahSesuai    int              `json:"jumlah_sesuai"`
+	Selisih         []InventoryDrift `json:"selisih"`
+	// BelumTercatat berisi stok yang belum pernah berubah sejak ledger dipakai. Saldo awalnya
+	// dicatat otomatis pada perubahan stok berikutnya.
+	BelumTercatat []InventoryDrift `json:"belum_tercatat"`
+}
+
+// SellerStockChange membuat StockChange untuk penyesuaian stok yang dilakukan penjual
+func SellerStockChange(userID uint, alasan string) StockChange {
+	return StockChange{
+		Tipe:   models.MovementAdjustment,
+		Alasan: alasan,
+		Aktor:  AktorSeller,
+		IDUser: &userID,
+	}
+}
+
+// AdjustStock menambah atau mengurangi stok produk (atau varian) milik toko user dan mencatatnya
+// di ledger. Pengurangan tidak boleh membuat stok negatif.
+func AdjustStock(userID, produkID uint, input StockAdjustmentInput) (*models.InventoryMovement, error) {
+	input.Alasan = strings.TrimSpace(input.Alasan)
+	if input.Alasan == "" {
+		return nil, ErrAlasanPenyesuaianKosong
+	}
+	if input.Kuantitas == 0 {
+		return nil, ErrPenyesuaianNol
+	}
+
+	var movement models.InventoryMovement
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockManagedProduct(tx, userID, produkID); err != nil {
+			return err
+		}
+		varian, err := resolveVariant(tx, produkID, input.IDVarian, true)
+		if err != nil {
+			return err
+		}
+		var varianID *uint
+		if varian != nil {
+			varianID = &varian.ID
+		}
+
+		change := SellerStockChange(userID, input.Alasan)
+		if input.Kuantitas < 0 {
+			err = ReserveStock(tx, produkID, varianID, -input.Kuantitas, change)
+		} else {
+			err = ReleaseStock(tx, produkID, varianID, input.Kuantitas, change)
+		}
+		if err != nil {
+			return err
+		}
+
+		return ledgerScope(tx, produkID, varianID).Order("id DESC").First(&movement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+// GetStockMovements mengambil riwayat pergerakan stok produk milik toko user dengan pagination.
+// Admin dapat melihat riwayat produk dari semua toko.
+func GetStockMovements(userID, produkID uint, filter StockMovementFilter) ([]models.InventoryMovement, int64, int, error) {
+	var produk models.Produk
+	if err := config.DB.First(&produk, produkID).Error; err != nil {
+		return nil, 0, 0, ErrProdukTidakDitemukan
+	}
+
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if !actor.IsAdmin && !actor.CanManageProduct(&produk) {
+		return nil, 0, 0, ErrAksesProdukDitolak
+	}
+
+	query := config.DB.Model(&models.InventoryMovement{}).Where("id_produk = ?", produkID)
+	if filter.IDVarian != 0 {
+		query = query.Where("id_varian = ?", filter.IDVarian)
+	}
+	if filter.Tipe != "" {
+		query = query.Where("tipe = ?", filter.Tipe)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	movements := []models.InventoryMovement{}
+	offset := (filter.Page - 1) * filter.Limit
+	if err := query.Order("id DESC").Limit(filter.Limit).Offset(offset).Find(&movements).Error; err != nil {
+		return nil, 0, 0, errors.New("gagal mengambil riwayat stok")
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(filter.Limit)))
+	return movements, total, totalPages, nil
+}
+
+// ReconcileInventory menghitung ulang stok dari ledger lalu membandingkannya dengan stok produk
+// tanpa varian dan stok setiap varian. Penjual memeriksa produk tokonya, admin semua toko.
+// Stok produk bervarian tidak diperiksa langsung karena selalu dihitung dari stok variannya.
+func ReconcileInventory(userID uint) (*InventoryReconciliation, error) {
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.IsAdmin && actor.TokoID == 0 {
+		return nil, ErrAksesProdukDitolak
+	}
+
+	productLedger := config.DB.Model(&models.InventoryMovement{}).
+		Select("id_produk, SUM(kuantitas) AS total, COUNT(*) AS jumlah").
+		Where("id_varian IS NULL").Group("id_produk")
+	products := config.DB.Table("produks AS p").
+		Select("p.id AS id_produk, p.nama_produk, p.stok, "+
+			"COALESCE(l.total, 0) AS stok_ledger, COALESCE(l.jumlah, 0) AS jumlah_ledger").
+		Joins("LEFT JOIN (?) AS l ON l.id_produk = p.id", productLedger).
+		Where("NOT EXISTS (?)", config.DB.Table("produk_varians AS v").Select("1").Where("v.id_produk = p.id"))
+
+	variantLedger := config.DB.Model(&models.InventoryMovement{}).
+		Select("id_varian, SUM(kuantitas) AS total, COUNT(*) AS jumlah").
+		Where("id_varian IS NOT NULL").Group("id_varian")
+	variants := config.DB.Table("produk_varians AS v").
+		Select("v.id_produk, v.id AS id_varian, p.nama_produk, v.sku, v.stok, "+
+			"COALESCE(l.total, 0) AS stok_ledger, COALESCE(l.jumlah, 0) AS jumlah_ledger").
+		Joins("JOIN produks AS p ON p.id = v.id_produk").
+		Joins("LEFT JOIN (?) AS l ON l.id_varian = v.id", variantLedger)
+
+	if !actor.IsAdmin {
+		products = products.Where("p.id_toko = ?", actor.TokoID)
+		variants = variants.Where("p.id_toko = ?", actor.TokoID)
+	}
+
+	var rows []InventoryDrift
+	if err := products.Order("p.id ASC").Scan(&rows).Error; err != nil {
+		return nil, errors.New("gagal mencocokkan stok produk")
+	}
+	var variantRows []InventoryDrift
+	if err := variants.Order("v.id_produk ASC, v.id ASC").Scan(&variantRows).Error; err != nil {
+		return nil, errors.New("gagal mencocokkan stok varian")
+	}
+	rows = append(rows, variantRows...)
+
+	result := &InventoryReconciliation{
+		DiperiksaPada:   time.Now(),
+		JumlahDiperiksa: len(rows),
+		Selisih:         []InventoryDrift{},
+		BelumTercatat:   []InventoryDrift{},
+	}
+	for _, row := range rows {
+		row.Selisih = row.Stok - row.StokLedger
+		switch {
+		case row.Selisih == 0:
+			result.JumlahSesuai++
+		case row.JumlahLedger == 0:
+			result.BelumTercatat = append(result.BelumTercatat, row)
+		default:
+			result.Selisih = append(result.Selisih, row)
+		}
+	}
+	return result, nil
+}
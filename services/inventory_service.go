@@ -0,0 +1,292 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrStokTidakMencukupi   = errors.New("stok produk tidak mencukupi")
+	ErrKuantitasTidakValid  = errors.New("kuantitas harus lebih dari 0")
+	ErrProdukTidakDitemukan = errors.New("produk tidak ditemukan")
+	ErrStokDikelolaVarian   = errors.New("stok produk bervarian diatur per varian")
+)
+
+// Semua perubahan stok produk harus melalui fungsi di file ini agar pengurangan stok
+// dilakukan secara kondisional di database, bukan dicek di Go lalu disimpan ulang.
+// Untuk produk bervarian, stok varian yang dikurangi dan stok produk ikut disesuaikan
+// sebagai jumlah stok seluruh varian. Setiap perubahan dicatat di ledger InventoryMovement
+// per produk (atau per varian untuk produk bervarian) dan diperiksa terhadap batas stok minimum.
+
+// StockChange menjelaskan penyebab perubahan stok untuk dicatat di ledger inventaris
+type StockChange struct {
+	Tipe      string // salah satu models.Movement*
+	Alasan    string
+	Aktor     string
+	IDUser    *uint
+	IDTrx     *uint
+	Referensi string // misalnya kode invoice atau nama file impor
+}
+
+// stockChangeForTransaction membuat StockChange untuk perubahan stok karena sebuah transaksi
+func stockChangeForTransaction(trx *models.Transaction, tipe, aktor string, userID *uint, alasan string) StockChange {
+	trxID := trx.ID
+	return StockChange{
+		Tipe:      tipe,
+		Alasan:    alasan,
+		Aktor:     aktor,
+		IDUser:    userID,
+		IDTrx:     &trxID,
+		Referensi: trx.KodeInvoice,
+	}
+}
+
+// ReserveStock mengurangi stok produk (atau varian jika varianID diisi) jika stok masih mencukupi.
+// Pengurangan dilakukan dengan `UPDATE ... WHERE stok >= ?` sehingga aman terhadap checkout paralel.
+func ReserveStock(tx *gorm.DB, produkID uint, varianID *uint, qty int, change StockChange) error {
+	if qty <= 0 {
+		return ErrKuantitasTidakValid
+	}
+
+	if varianID != nil {
+		result := tx.Model(&models.ProdukVarian{}).
+			Where("id = ? AND id_produk = ? AND stok >= ?", *varianID, produkID, qty).
+			Update("stok", gorm.Expr("stok - ?", qty))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			tx.Model(&models.ProdukVarian{}).Where("id = ? AND id_produk = ?", *varianID, produkID).Count(&count)
+			if count == 0 {
+				return ErrVarianTidakDitemukan
+			}
+			return ErrStokTidakMencukupi
+		}
+		if err := tx.Model(&models.Produk{}).Where("id = ?", produkID).
+			Update("stok", gorm.Expr("stok - ?", qty)).Error; err != nil {
+			return err
+		}
+		return recordMovement(tx, produkID, varianID, -qty, change)
+	}
+
+	result := tx.Model(&models.Produk{}).
+		Where("id = ? AND stok >= ?", produkID, qty).
+		Update("stok", gorm.Expr("stok - ?", qty))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var count int64
+		tx.Model(&models.Produk{}).Where("id = ?", produkID).Count(&count)
+		if count == 0 {
+			return ErrProdukTidakDitemukan
+		}
+		return ErrStokTidakMencukupi
+	}
+	return recordMovement(tx, produkID, nil, -qty, change)
+}
+
+// ReleaseStock mengembalikan stok produk (atau varian), misalnya saat transaksi dibatalkan.
+// Produk yang sudah diarsipkan tetap menerima stok kembali agar pembatalan tidak terhalang.
+func ReleaseStock(tx *gorm.DB, produkID uint, varianID *uint, qty int, change StockChange) error {
+	if qty <= 0 {
+		return ErrKuantitasTidakValid
+	}
+
+	if varianID != nil {
+		result := tx.Model(&models.ProdukVarian{}).
+			Where("id = ? AND id_produk = ?", *varianID, produkID).
+			Update("stok", gorm.Expr("stok + ?", qty))
+		if result.Error != nil {
+			return result.Error
+		}
+		// Varian yang sudah dihapus tidak memiliki stok untuk dikembalikan, dan stok produk
+		// bervarian adalah jumlah stok variannya sehingga ikut tidak berubah
+		if result.RowsAffected == 0 {
+			return nil
+		}
+	}
+
+	result := tx.Unscoped().Model(&models.Produk{}).
+		Where("id = ?", produkID).
+		Update("stok", gorm.Expr("stok + ?", qty))
+	if result.Error != nil {
+		return result.Error
+	}
+	// Produk yang sudah dihapus permanen oleh purge tidak memiliki stok untuk dikembalikan
+	if result.RowsAffected == 0 {
+		return nil
+	}
+	return recordMovement(tx, produkID, varianID, qty, change)
+}
+
+// SetStock mengganti nilai stok produk secara langsung, misalnya saat penjual mengubah stok.
+// Selisih terhadap stok lama dicatat di ledger. Produk bervarian harus diubah melalui
+// SetVariantStock.
+func SetStock(tx *gorm.DB, produkID uint, stok int, change StockChange) error {
+	if stok < 0 {
+		return errors.New("stok tidak boleh negatif")
+	}
+
+	if hasVariants(tx, produkID) {
+		return ErrStokDikelolaVarian
+	}
+
+	var produk models.Produk
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stok").
+		First(&produk, produkID).Error; err != nil {
+		return ErrProdukTidakDitemukan
+	}
+
+	if err := tx.Model(&models.Produk{}).Where("id = ?", produkID).Update("stok", stok).Error; err != nil {
+		return err
+	}
+	return recordMovement(tx, produkID, nil, stok-produk.Stok, change)
+}
+
+// SetVariantStock mengganti stok satu varian lalu menghitung ulang stok produk induknya
+func SetVariantStock(tx *gorm.DB, produkID, varianID uint, stok int, change StockChange) error {
+	if stok < 0 {
+		return errors.New("stok tidak boleh negatif")
+	}
+
+	if varianID == 0 {
+		return ErrVarianTidakDitemukan
+	}
+	varian, err := resolveVariant(tx, produkID, varianID, true)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.ProdukVarian{}).Where("id = ?", varian.ID).Update("stok", stok).Error; err != nil {
+		return err
+	}
+	if err := recordMovement(tx, produkID, &varian.ID, stok-varian.Stok, change); err != nil {
+		return err
+	}
+	return syncVariantStock(tx, produkID)
+}
+
+// syncVariantStock menyamakan stok produk dengan jumlah stok seluruh variannya
+func syncVariantStock(tx *gorm.DB, produkID uint) error {
+	return tx.Unscoped().Model(&models.Produk{}).Where("id = ?", produkID).
+		Update("stok", tx.Model(&models.ProdukVarian{}).
+			Select("COALESCE(SUM(stok), 0)").Where("id_produk = ?", produkID)).Error
+}
+
+// rebaseLedgerToVariants menutup saldo ledger tingkat produk (id_varian NULL) saat stok produk
+// mulai dikelola per varian. Stok lama dicatat keluar karena digantikan stok varian; selama
+// bervarian, stok produk hanya ringkasan varian dan tidak dicatat di ledger produk.
+func rebaseLedgerToVariants(tx *gorm.DB, produkID uint, change StockChange) error {
+	stok, err := currentStock(tx, produkID, nil)
+	if err != nil {
+		return err
+	}
+	change.Alasan = "Stok dipindahkan ke varian"
+	return insertMovement(tx, produkID, nil, -stok, 0, change)
+}
+
+// rebaseLedgerFromVariants menyamakan saldo ledger tingkat produk dengan stok produk saat produk
+// kembali dijual tanpa varian, sehingga rekonsiliasi tidak menampilkan selisih palsu
+func rebaseLedgerFromVariants(tx *gorm.DB, produkID uint, change StockChange) error {
+	stok, err := currentStock(tx, produkID, nil)
+	if err != nil {
+		return err
+	}
+	var saldo int
+	if err := ledgerScope(tx.Model(&models.InventoryMovement{}), produkID, nil).
+		Select("COALESCE(SUM(kuantitas), 0)").Scan(&saldo).Error; err != nil {
+		return err
+	}
+	change.Alasan = "Stok dipindahkan dari varian"
+	return insertMovement(tx, produkID, nil, stok-saldo, stok, change)
+}
+
+// currentStock membaca stok produk, atau stok varian jika varianID diisi
+func currentStock(tx *gorm.DB, produkID uint, varianID *uint) (int, error) {
+	var stok int
+	query := tx.Unscoped().Model(&models.Produk{}).Where("id = ?", produkID)
+	if varianID != nil {
+		query = tx.Model(&models.ProdukVarian{}).Where("id = ?", *varianID)
+	}
+	err := query.Select("stok").Scan(&stok).Error
+	return stok, err
+}
+
+// ledgerScope membatasi query ledger pada satu produk tanpa varian atau satu varian
+func ledgerScope(db *gorm.DB, produkID uint, varianID *uint) *gorm.DB {
+	if varianID != nil {
+		return db.Where("id_produk = ? AND id_varian = ?", produkID, *varianID)
+	}
+	return db.Where("id_produk = ? AND id_varian IS NULL", produkID)
+}
+
+// recordMovement menambahkan satu baris ledger setelah stok diubah, lalu memeriksa apakah stok
+// baru turun melewati batas minimum
+func recordMovement(tx *gorm.DB, produkID uint, varianID *uint, delta int, change StockChange) error {
+	if delta == 0 {
+		return nil
+	}
+
+	stokSesudah, err := currentStock(tx, produkID, varianID)
+	if err != nil {
+		return err
+	}
+	if err := insertMovement(tx, produkID, varianID, delta, stokSesudah, change); err != nil {
+		return err
+	}
+	return detectLowStock(tx, produkID, varianID, stokSesudah-delta, stokSesudah)
+}
+
+// insertMovement menyimpan satu baris ledger. Produk atau varian yang stoknya sudah ada sebelum
+// ledger dipakai mendapat baris saldo awal lebih dulu, sehingga jumlah kuantitas ledger selalu
+// sama dengan stok.
+func insertMovement(tx *gorm.DB, produkID uint, varianID *uint, delta, stokSesudah int, change StockChange) error {
+	if delta == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := ledgerScope(tx.Model(&models.InventoryMovement{}), produkID, varianID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 && stokSesudah-delta != 0 {
+		opening := models.InventoryMovement{
+			IDProduk:    produkID,
+			IDVarian:    varianID,
+			Tipe:        models.MovementAdjustment,
+			Kuantitas:   stokSesudah - delta,
+			StokSesudah: stokSesudah - delta,
+			Alasan:      "Saldo awal sebelum pencatatan ledger",
+			Aktor:       AktorSystem,
+		}
+		if err := tx.Create(&opening).Error; err != nil {
+			return err
+		}
+	}
+
+	movement := models.InventoryMovement{
+		IDProduk:    produkID,
+		IDVarian:    varianID,
+		Tipe:        change.Tipe,
+		Kuantitas:   delta,
+		StokSesudah: stokSesudah,
+		Alasan:      change.Alasan,
+		Aktor:       change.Aktor,
+		IDUser:      change.IDUser,
+		IDTrx:       change.IDTrx,
+		Referensi:   change.Referensi,
+	}
+	return tx.Create(&movement).Error
+}
+
+// RecordStockMovement mencatat perubahan stok yang dilakukan di luar fungsi di file ini,
+// misalnya stok awal produk yang ikut tersimpan saat produk dibuat
+func RecordStockMovement(tx *gorm.DB, produkID uint, varianID *uint, delta int, change StockChange) error {
+	return recordMovement(tx, produkID, varianID, delta, change)
+}
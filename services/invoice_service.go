@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kode toko untuk transaksi yang berisi produk dari beberapa toko
+const KodeTokoMulti = "MULTI"
+
+var ErrFormatInvoiceTidakValid = errors.New("format invoice tidak valid")
+
+// InvoiceFormat mengatur bentuk nomor invoice.
+// Template mendukung placeholder {PREFIX}, {DATE}, {STORE} dan {SEQ}.
+type InvoiceFormat struct {
+	Prefix     string
+	Template   string
+	DateLayout string
+	SeqDigits  int
+}
+
+// LoadInvoiceFormat membaca format invoice dari environment variable
+func LoadInvoiceFormat() InvoiceFormat {
+	format := InvoiceFormat{
+		Prefix:     "INV",
+		Template:   "{PREFIX}-{DATE}-{STORE}-{SEQ}",
+		DateLayout: "20060102",
+		SeqDigits:  5,
+	}
+
+	if v := os.Getenv("INVOICE_PREFIX"); v != "" {
+		format.Prefix = v
+	}
+	if v := os.Getenv("INVOICE_FORMAT"); v != "" {
+		format.Template = v
+	}
+	if v := os.Getenv("INVOICE_DATE_LAYOUT"); v != "" {
+		format.DateLayout = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("INVOICE_SEQ_DIGITS")); err == nil && v > 0 {
+		format.SeqDigits = v
+	}
+
+	return format
+}
+
+// Validate memastikan template menghasilkan kode invoice yang unik. {SEQ} wajib ada karena
+// hanya nomor urut yang membedakan invoice dengan tanggal dan toko yang sama.
+func (f InvoiceFormat) Validate() error {
+	if !strings.Contains(f.Template, "{SEQ}") {
+		return fmt.Errorf("%w: template %q harus memuat {SEQ}", ErrFormatInvoiceTidakValid, f.Template)
+	}
+	if strings.Contains(f.Template, "{DATE}") && f.DateLayout == "" {
+		return fmt.Errorf("%w: INVOICE_DATE_LAYOUT wajib diisi jika template memuat {DATE}", ErrFormatInvoiceTidakValid)
+	}
+	return nil
+}
+
+// StoreCode menghasilkan kode toko yang dipakai di nomor invoice
+func StoreCode(tokoID uint) string {
+	return fmt.Sprintf("T%04d", tokoID)
+}
+
+// StoreCodeForProducts menentukan kode toko dari daftar produk yang dibeli
+func StoreCodeForProducts(db *gorm.DB, produkIDs []uint) string {
+	var tokoIDs []uint
+	db.Model(&models.Produk{}).Where("id IN ?", produkIDs).Distinct().Pluck("id_toko", &tokoIDs)
+	if len(tokoIDs) == 1 {
+		return StoreCode(tokoIDs[0])
+	}
+	return KodeTokoMulti
+}
+
+// Render menyusun nomor invoice dari template
+func (f InvoiceFormat) Render(tanggal, kodeToko string, nomor int) string {
+	replacer := strings.NewReplacer(
+		"{PREFIX}", f.Prefix,
+		"{DATE}", tanggal,
+		"{STORE}", kodeToko,
+		"{SEQ}", fmt.Sprintf("%0*d", f.SeqDigits, nomor),
+	)
+	return replacer.Replace(f.Template)
+}
+
+// NextInvoiceNumber mengambil nomor urut berikutnya dan menyusun kode invoice. Nomor urut direset
+// setiap hari jika template memuat {DATE}.
+// Nomor urut diambil di DB transaction tersendiri dengan row lock agar aman terhadap checkout
+// paralel tanpa menahan lock selama proses checkout; checkout yang gagal akan meninggalkan
+// celah nomor urut.
+func NextInvoiceNumber(kodeToko string) (string, error) {
+	format := LoadInvoiceFormat()
+	if err := format.Validate(); err != nil {
+		return "", err
+	}
+	tanggal := time.Now().Format(format.DateLayout)
+
+	// Jika template tidak memakai kode toko, nomor urut berlaku global per hari
+	seqKodeToko := kodeToko
+	if !strings.Contains(format.Template, "{STORE}") {
+		seqKodeToko = ""
+	}
+	// Jika template tidak memakai tanggal, nomor urut tidak pernah direset
+	seqTanggal := tanggal
+	if !strings.Contains(format.Template, "{DATE}") {
+		seqTanggal = ""
+	}
+	// Prefix yang tidak tampil di kode invoice tidak boleh memisahkan nomor urut
+	seqPrefix := format.Prefix
+	if !strings.Contains(format.Template, "{PREFIX}") {
+		seqPrefix = ""
+	}
+
+	var nomor int
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		seq := models.InvoiceSequence{
+			Prefix:   seqPrefix,
+			Tanggal:  seqTanggal,
+			KodeToko: seqKodeToko,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&seq).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("prefix = ? AND tanggal = ? AND kode_toko = ?", seqPrefix, seqTanggal, seqKodeToko).
+			First(&seq).Error; err != nil {
+			return err
+		}
+
+		seq.Nomor++
+		nomor = seq.Nomor
+		return tx.Model(&seq).Update("nomor", seq.Nomor).Error
+	})
+	if err != nil {
+		return "", fmt.Errorf("gagal membuat nomor invoice: %w", err)
+	}
+
+	return format.Render(tanggal, kodeToko, nomor), nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LowStockItem adalah produk tanpa varian atau varian yang stoknya sudah mencapai batas minimum
+type LowStockItem struct {
+	IDProduk    uint   `json:"id_produk"`
+	IDVarian    *uint  `json:"id_varian"`
+	IDToko      uint   `json:"id_toko"`
+	NamaProduk  string `json:"nama_produk"`
+	NamaVarian  string `json:"nama_varian"`
+	SKU         string `json:"sku"`
+	Stok        int    `json:"stok"`
+	StokMinimum int    `json:"stok_minimum"`
+}
+
+// label mengembalikan nama produk beserta nama varian jika ada
+func (i LowStockItem) label() string {
+	if i.NamaVarian == "" {
+		return i.NamaProduk
+	}
+	return i.NamaProduk + " (" + i.NamaVarian + ")"
+}
+
+// detectLowStock membuat notifikasi untuk pemilik toko saat stok produk (atau varian) turun
+// melewati batas minimum produk. Peringatan hanya dibuat saat batas terlewati, bukan setiap kali
+// stok berkurang di bawah batas, sehingga penjual tidak dibanjiri notifikasi.
+func detectLowStock(tx *gorm.DB, produkID uint, varianID *uint, stokSebelum, stokSesudah int) error {
+	if stokSesudah >= stokSebelum {
+		return nil
+	}
+
+	var produk models.Produk
+	if err := tx.Unscoped().Select("id", "id_toko", "nama_produk", "stok_minimum", "deleted_at").
+		First(&produk, produkID).Error; err != nil {
+		return err
+	}
+	// Produk yang diarsipkan tidak dijual sehingga tidak perlu diberi peringatan
+	if produk.DeletedAt.Valid || stokSebelum <= produk.StokMinimum || stokSesudah > produk.StokMinimum {
+		return nil
+	}
+
+	item := LowStockItem{
+		IDProduk:    produk.ID,
+		IDVarian:    varianID,
+		IDToko:      produk.IDToko,
+		NamaProduk:  produk.NamaProduk,
+		Stok:        stokSesudah,
+		StokMinimum: produk.StokMinimum,
+	}
+	if varianID != nil {
+		var varian models.ProdukVarian
+		// Varian yang dihapus tidak perlu diberi peringatan
+		if err := tx.Select("id", "nama", "sku").First(&varian, *varianID).Error; err != nil {
+			return nil
+		}
+		item.NamaVarian = varian.Nama
+		item.SKU = varian.SKU
+	}
+
+	var toko models.Toko
+	if err := tx.First(&toko, produk.IDToko).Error; err != nil {
+		return err
+	}
+
+	notif := models.Notifikasi{
+		IDUser:   toko.IDUser,
+		IDToko:   &toko.ID,
+		IDProduk: &item.IDProduk,
+		IDVarian: item.IDVarian,
+	}
+	if stokSesudah <= 0 {
+		notif.Tipe = models.NotifikasiStokHabis
+		notif.Judul = "Stok habis"
+		notif.Pesan = fmt.Sprintf("Stok %s habis. Produk tidak dapat dibeli sampai stok ditambah.", item.label())
+	} else {
+		notif.Tipe = models.NotifikasiStokMenipis
+		notif.Judul = "Stok menipis"
+		notif.Pesan = fmt.Sprintf("Stok %s tinggal %d, sudah mencapai batas minimum %d.",
+			item.label(), stokSesudah, produk.StokMinimum)
+	}
+
+	// Toko dengan ringkasan harian tetap melihat peringatan di aplikasi, tetapi hanya ringkasan
+	// yang dikirim ke luar aplikasi
+	return createNotification(tx, &notif, toko.DigestStok)
+}
+
+// lowStockItems mengambil produk tanpa varian dan varian yang stoknya tidak melebihi batas minimum
+// produk, tidak termasuk produk yang diarsipkan. Jika tokoID diisi, hanya produk toko tersebut
+// yang diambil.
+func lowStockItems(db *gorm.DB, tokoID *uint) ([]LowStockItem, error) {
+	products := db.Table("produks AS p").
+		Select("p.id AS id_produk, p.id_toko, p.nama_produk, p.stok, p.stok_minimum").
+		Where("p.stok <= p.stok_minimum AND p.deleted_at IS NULL").
+		Where("NOT EXISTS (?)", db.Table("produk_varians AS v").Select("1").Where("v.id_produk = p.id"))
+	variants := db.Table("produk_varians AS v").
+		Select("v.id_produk, v.id AS id_varian, p.id_toko, p.nama_produk, v.nama AS nama_varian, v.sku, " +
+			"v.stok, p.stok_minimum").
+		Joins("JOIN produks AS p ON p.id = v.id_produk").
+		Where("v.stok <= p.stok_minimum AND p.deleted_at IS NULL")
+
+	if tokoID != nil {
+		products = products.Where("p.id_toko = ?", *tokoID)
+		variants = variants.Where("p.id_toko = ?", *tokoID)
+	}
+
+	var items []LowStockItem
+	if err := products.Order("p.stok ASC, p.id ASC").Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	var variantItems []LowStockItem
+	if err := variants.Order("v.stok ASC, v.id ASC").Scan(&variantItems).Error; err != nil {
+		return nil, err
+	}
+	return append(items, variantItems...), nil
+}
+
+// GetLowStockItems mengambil produk dan varian toko user yang stoknya menipis atau habis.
+// Admin melihat produk dari semua toko.
+func GetLowStockItems(userID uint) ([]LowStockItem, error) {
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokoID *uint
+	if !actor.IsAdmin {
+		if actor.TokoID == 0 {
+			return nil, ErrAksesProdukDitolak
+		}
+		tokoID = &actor.TokoID
+	}
+
+	items, err := lowStockItems(config.DB, tokoID)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []LowStockItem{}
+	}
+	return items, nil
+}
+
+// CreateLowStockDigests membuat ringkasan stok menipis untuk toko yang memilih ringkasan harian.
+// Ringkasan dibuat sekali sehari setelah jam cfg.DigestHour dan hanya jika ada stok yang menipis.
+// Toko dikunci selama pengecekan agar beberapa instance aplikasi tidak membuat ringkasan ganda.
+// Setiap toko diproses di transaksi tersendiri; kegagalan satu toko dicatat di log tanpa
+// menghentikan toko lain.
+func CreateLowStockDigests(now time.Time, cfg NotificationConfig) (int, error) {
+	if now.Hour() < cfg.DigestHour {
+		return 0, nil
+	}
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var tokoIDs []uint
+	if err := config.DB.Model(&models.Toko{}).Where("digest_stok = ?", true).
+		Pluck("id", &tokoIDs).Error; err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, tokoID := range tokoIDs {
+		dibuat := false
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var toko models.Toko
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&toko, tokoID).Error; err != nil {
+				return err
+			}
+
+			var count int64
+			tx.Model(&models.Notifikasi{}).
+				Where("id_toko = ? AND tipe = ? AND created_at >= ?", toko.ID, models.NotifikasiDigestStokHarian, startOfDay).
+				Count(&count)
+			if count > 0 {
+				return nil
+			}
+
+			items, err := lowStockItems(tx, &toko.ID)
+			if err != nil || len(items) == 0 {
+				return err
+			}
+
+			lines := make([]string, 0, len(items))
+			for _, item := range items {
+				lines = append(lines, fmt.Sprintf("- %s: stok %d (minimum %d)", item.label(), item.Stok, item.StokMinimum))
+			}
+			notif := models.Notifikasi{
+				IDUser: toko.IDUser,
+				IDToko: &toko.ID,
+				Tipe:   models.NotifikasiDigestStokHarian,
+				Judul:  fmt.Sprintf("Ringkasan stok %s", now.Format("02-01-2006")),
+				Pesan: fmt.Sprintf("%d produk di %s perlu ditambah stoknya:\n%s",
+					len(items), toko.NamaToko, strings.Join(lines, "\n")),
+			}
+			if err := createNotification(tx, &notif, false); err != nil {
+				return err
+			}
+			dibuat = true
+			return nil
+		})
+		if err != nil {
+			log.Printf("Gagal membuat ringkasan stok toko %d: %v", tokoID, err)
+			continue
+		}
+		if dibuat {
+			created++
+		}
+	}
+	return created, nil
+}
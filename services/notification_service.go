@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+)
+
+var ErrNotifikasiTidakDitemukan = errors.New("notifikasi tidak ditemukan")
+
+// createNotification menyimpan notifikasi in-app di DB transaction yang sedang berjalan.
+// Notifikasi baru menunggu dikirim scheduler jika notifier dikonfigurasi; jika tidak, atau jika
+// inApp bernilai true, notifikasi hanya ditampilkan di aplikasi.
+func createNotification(tx *gorm.DB, notif *models.Notifikasi, inApp bool) error {
+	notif.StatusKirim = models.NotifikasiKirimPending
+	if _, err := GetNotifier(); err != nil || inApp {
+		notif.StatusKirim = models.NotifikasiKirimSkipped
+	}
+	return tx.Create(notif).Error
+}
+
+// GetNotifications mengambil notifikasi user dengan pagination, terbaru lebih dulu
+func GetNotifications(userID uint, unreadOnly bool, page, limit int) ([]models.Notifikasi, int64, int, error) {
+	query := config.DB.Model(&models.Notifikasi{}).Where("id_user = ?", userID)
+	if unreadOnly {
+		query = query.Where("dibaca_pada IS NULL")
+	}
+
+	var total int64
+	query.Count(&total)
+
+	notifications := []models.Notifikasi{}
+	offset := (page - 1) * limit
+	if err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&notifications).Error; err != nil {
+		return nil, 0, 0, errors.New("gagal mengambil notifikasi")
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	return notifications, total, totalPages, nil
+}
+
+// CountUnreadNotifications menghitung notifikasi user yang belum dibaca
+func CountUnreadNotifications(userID uint) int64 {
+	var count int64
+	config.DB.Model(&models.Notifikasi{}).Where("id_user = ? AND dibaca_pada IS NULL", userID).Count(&count)
+	return count
+}
+
+// MarkNotificationRead menandai satu notifikasi milik user sudah dibaca
+func MarkNotificationRead(userID, notifID uint) (*models.Notifikasi, error) {
+	var notif models.Notifikasi
+	if err := config.DB.Where("id = ? AND id_user = ?", notifID, userID).First(&notif).Error; err != nil {
+		return nil, ErrNotifikasiTidakDitemukan
+	}
+
+	if notif.DibacaPada == nil {
+		now := time.Now()
+		if err := config.DB.Model(&notif).Update("dibaca_pada", now).Error; err != nil {
+			return nil, err
+		}
+		notif.DibacaPada = &now
+	}
+	return &notif, nil
+}
+
+// MarkAllNotificationsRead menandai seluruh notifikasi user sudah dibaca
+func MarkAllNotificationsRead(userID uint) (int64, error) {
+	result := config.DB.Model(&models.Notifikasi{}).
+		Where("id_user = ? AND dibaca_pada IS NULL", userID).
+		Update("dibaca_pada", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// NotificationConfig mengatur scheduler pengiriman notifikasi dan ringkasan stok harian
+type NotificationConfig struct {
+	Interval     time.Duration // jeda antar pengiriman
+	BatchSize    int           // jumlah notifikasi maksimal per pengiriman
+	MaxPercobaan int           // batas percobaan sebelum notifikasi ditandai gagal
+	DigestHour   int           // jam (waktu server) ringkasan stok harian mulai dibuat
+}
+
+// LoadNotificationConfig membaca konfigurasi notifikasi dari environment variable
+func LoadNotificationConfig() NotificationConfig {
+	cfg := NotificationConfig{
+		Interval:     time.Minute,
+		BatchSize:    100,
+		MaxPercobaan: 5,
+		DigestHour:   8,
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("NOTIFIER_POLL_INTERVAL")); err == nil && v > 0 {
+		cfg.Interval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFIER_BATCH_SIZE")); err == nil && v > 0 {
+		cfg.BatchSize = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFIER_MAX_ATTEMPTS")); err == nil && v > 0 {
+		cfg.MaxPercobaan = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("NOTIFIER_DIGEST_HOUR")); err == nil && v >= 0 && v < 24 {
+		cfg.DigestHour = v
+	}
+
+	return cfg
+}
+
+// DeliverPendingNotifications mengirim notifikasi yang menunggu melalui notifier. Setiap
+// notifikasi diklaim dengan menaikkan percobaan secara kondisional agar tidak dikirim dua kali
+// oleh instance lain, dan notifier dipanggil di luar DB transaction.
+func DeliverPendingNotifications(notifier Notifier, cfg NotificationConfig) (int, error) {
+	var pending []models.Notifikasi
+	if err := config.DB.Where("status_kirim = ?", models.NotifikasiKirimPending).
+		Order("id ASC").Limit(cfg.BatchSize).Find(&pending).Error; err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, notif := range pending {
+		result := config.DB.Model(&models.Notifikasi{}).
+			Where("id = ? AND status_kirim = ? AND percobaan = ?", notif.ID, models.NotifikasiKirimPending, notif.Percobaan).
+			Update("percobaan", gorm.Expr("percobaan + 1"))
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+		notif.Percobaan++
+
+		var user models.User
+		err := config.DB.First(&user, notif.IDUser).Error
+		if err == nil {
+			err = notifier.Send(&user, &notif)
+		}
+
+		updates := map[string]interface{}{}
+		switch {
+		case err == nil:
+			updates["status_kirim"] = models.NotifikasiKirimSent
+			updates["dikirim_pada"] = time.Now()
+			sent++
+		case notif.Percobaan >= cfg.MaxPercobaan:
+			updates["status_kirim"] = models.NotifikasiKirimFailed
+		}
+		if err != nil {
+			log.Printf("Gagal mengirim notifikasi %d melalui %s: %v", notif.ID, notifier.Name(), err)
+		}
+		if len(updates) > 0 {
+			if err := config.DB.Model(&models.Notifikasi{}).Where("id = ?", notif.ID).Updates(updates).Error; err != nil {
+				return sent, err
+			}
+		}
+	}
+	return sent, nil
+}
+
+// StartNotificationScheduler menjalankan pembuatan ringkasan stok harian dan, jika notifier
+// dikonfigurasi, pengiriman notifikasi secara berkala di background. Fungsi yang dikembalikan
+// dipakai untuk menghentikannya.
+func StartNotificationScheduler() func() {
+	notifier, err := GetNotifier()
+	if err != nil {
+		log.Println("Pengiriman notifikasi ke luar aplikasi dinonaktifkan:", err)
+	}
+
+	cfg := LoadNotificationConfig()
+	ticker := time.NewTicker(cfg.Interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := CreateLowStockDigests(time.Now(), cfg); err != nil {
+					log.Printf("Ringkasan stok harian gagal: %v", err)
+				}
+				if notifier != nil {
+					if _, err := DeliverPendingNotifications(notifier, cfg); err != nil {
+						log.Printf("Scheduler notifikasi gagal: %v", err)
+					}
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
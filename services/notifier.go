@@ -0,0 +1,124 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// Nama notifier untuk mengirim notifikasi ke luar aplikasi
+const (
+	NotifierLog     = "log"
+	NotifierWebhook = "webhook"
+)
+
+var ErrNotifierTidakTersedia = errors.New("notifier belum dikonfigurasi")
+
+// Notifier adalah kontrak yang harus dipenuhi setiap saluran pengiriman notifikasi, misalnya
+// email, WhatsApp atau push notification
+type Notifier interface {
+	// Name mengembalikan nama notifier
+	Name() string
+	// Send mengirim notifikasi ke user penerima
+	Send(user *models.User, notif *models.Notifikasi) error
+}
+
+var notifiers = map[string]Notifier{
+	NotifierLog:     &LogNotifier{},
+	NotifierWebhook: &WebhookNotifier{},
+}
+
+// GetNotifier mengambil notifier yang dipilih lewat NOTIFIER_PROVIDER. Tanpa konfigurasi,
+// notifikasi hanya ditampilkan di aplikasi.
+func GetNotifier() (Notifier, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("NOTIFIER_PROVIDER")))
+	if name == "" {
+		return nil, ErrNotifierTidakTersedia
+	}
+
+	notifier, ok := notifiers[name]
+	if !ok {
+		return nil, ErrNotifierTidakTersedia
+	}
+	return notifier, nil
+}
+
+// LogNotifier menulis notifikasi ke log aplikasi, berguna untuk pengembangan lokal
+type LogNotifier struct{}
+
+func (n *LogNotifier) Name() string { return NotifierLog }
+
+func (n *LogNotifier) Send(user *models.User, notif *models.Notifikasi) error {
+	log.Printf("Notifikasi untuk %s <%s>: %s - %s", user.Nama, user.Email, notif.Judul, notif.Pesan)
+	return nil
+}
+
+// WebhookNotifier mengirim notifikasi sebagai JSON ke NOTIFIER_WEBHOOK_URL, misalnya layanan
+// email atau WhatsApp internal. Payload ditandatangani dengan NOTIFIER_WEBHOOK_SECRET di header
+// X-Signature memakai skema yang sama dengan notifikasi pembayaran.
+type WebhookNotifier struct{}
+
+type webhookNotification struct {
+	ID        uint      `json:"id"`
+	Tipe      string    `json:"tipe"`
+	Judul     string    `json:"judul"`
+	Pesan     string    `json:"pesan"`
+	IDUser    uint      `json:"id_user"`
+	Nama      string    `json:"nama"`
+	Email     string    `json:"email"`
+	NoTelp    string    `json:"no_telp"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (n *WebhookNotifier) Name() string { return NotifierWebhook }
+
+func (n *WebhookNotifier) Send(user *models.User, notif *models.Notifikasi) error {
+	url := os.Getenv("NOTIFIER_WEBHOOK_URL")
+	if url == "" {
+		return ErrNotifierTidakTersedia
+	}
+
+	payload, err := json.Marshal(webhookNotification{
+		ID:        notif.ID,
+		Tipe:      notif.Tipe,
+		Judul:     notif.Judul,
+		Pesan:     notif.Pesan,
+		IDUser:    user.ID,
+		Nama:      user.Nama,
+		Email:     user.Email,
+		NoTelp:    user.NoTelp,
+		CreatedAt: notif.CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret := os.Getenv("NOTIFIER_WEBHOOK_SECRET"); secret != "" {
+		req.Header.Set("X-Signature", SignPayload(secret, payload))
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook notifikasi membalas status %d", resp.StatusCode)
+	}
+	return nil
+}
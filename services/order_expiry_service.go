@@ -0,0 +1,185 @@
+package services
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+)
+
+// OrderExpiryConfig mengatur scheduler kadaluarsa transaksi yang belum dibayar
+type OrderExpiryConfig struct {
+	Enabled   bool
+	Deadline  time.Duration // batas waktu pembayaran sejak transaksi dibuat
+	Interval  time.Duration // jeda antar pengecekan
+	BatchSize int           // jumlah transaksi maksimal per pengecekan
+}
+
+// LoadOrderExpiryConfig membaca konfigurasi scheduler dari environment variable.
+// Batas waktu pembayaran mengikuti masa berlaku tagihan jika ORDER_PAYMENT_DEADLINE tidak diisi.
+func LoadOrderExpiryConfig() OrderExpiryConfig {
+	cfg := OrderExpiryConfig{
+		Enabled:   os.Getenv("ORDER_EXPIRY_ENABLED") != "false",
+		Deadline:  paymentExpiry(),
+		Interval:  time.Minute,
+		BatchSize: 100,
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("ORDER_PAYMENT_DEADLINE")); err == nil && v > 0 {
+		cfg.Deadline = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("ORDER_EXPIRY_INTERVAL")); err == nil && v > 0 {
+		cfg.Interval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("ORDER_EXPIRY_BATCH_SIZE")); err == nil && v > 0 {
+		cfg.BatchSize = v
+	}
+
+	return cfg
+}
+
+// orderPaymentDeadline mengembalikan batas pembayaran transaksi. Transaksi yang dibuat sebelum
+// batas disimpan saat checkout memakai waktu pembuatan ditambah batas yang berlaku sekarang.
+func orderPaymentDeadline(trx *models.Transaction) time.Time {
+	if trx.BatasBayar != nil {
+		return *trx.BatasBayar
+	}
+	return trx.CreatedAt.Add(LoadOrderExpiryConfig().Deadline)
+}
+
+// expirableOrders membatasi query pada transaksi menunggu pembayaran yang melewati batasnya.
+// Transaksi tanpa batas bayar hanya ikut jika sudah memiliki tagihan, sehingga pesanan lama
+// yang tidak pernah melalui alur pembayaran tidak dikadaluarsakan dan stoknya tidak dikembalikan.
+func expirableOrders(db *gorm.DB, now, cutoff time.Time) *gorm.DB {
+	return db.Where("status = ?", models.TrxStatusPendingPayment).
+		Where("(batas_bayar IS NOT NULL AND batas_bayar < ?) OR (batas_bayar IS NULL AND created_at < ? AND EXISTS (?))",
+			now, cutoff, db.Session(&gorm.Session{NewDB: true}).Model(&models.Payment{}).Select("1").
+				Where("payments.id_trx = transactions.id"))
+}
+
+// expireTransaction mengubah transaksi menjadi kadaluarsa, membatalkan tagihan yang masih
+// menunggu, menutup pengajuan refund, mengembalikan kuota voucher dan stok produk
+func expireTransaction(tx *gorm.DB, trx *models.Transaction) error {
+	// Stok dikembalikan sebelum status berubah agar sub-transaksi yang sudah dibatalkan dilewati
+	if err := restoreTransactionStock(tx, trx.ID,
+		stockChangeForTransaction(trx, models.MovementCancel, AktorSystem, nil, "Transaksi kadaluarsa")); err != nil {
+		return err
+	}
+	if err := ApplyTransactionStatus(tx, trx, models.TrxStatusExpired, AktorSystem, nil,
+		"Batas waktu pembayaran terlewati"); err != nil {
+		return err
+	}
+
+	if err := tx.Model(&models.Payment{}).
+		Where("id_trx = ? AND status = ?", trx.ID, models.PaymentStatusPending).
+		Update("status", models.PaymentStatusExpired).Error; err != nil {
+		return err
+	}
+
+	if err := releaseVoucherUsage(tx, trx.ID); err != nil {
+		return err
+	}
+	return closeOpenRefunds(tx, trx.ID, nil, AktorSystem, nil, "Transaksi kadaluarsa")
+}
+
+// expireOrder mengunci dan mengkadaluarsakan satu transaksi di DB transaction tersendiri. Tagihan
+// dikunci lebih dulu baru transaksinya, sama dengan urutan webhook pembayaran, agar keduanya tidak
+// saling deadlock. Transaksi yang sudah dibayar atau dibatalkan sementara itu dilewati.
+func expireOrder(trxID uint, now, cutoff time.Time) (bool, error) {
+	expired := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockPendingPayments(tx, trxID); err != nil {
+			return err
+		}
+
+		var count int64
+		if err := expirableOrders(tx.Model(&models.Transaction{}), now, cutoff).
+			Where("id = ?", trxID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+
+		trx, err := LockTransaction(tx, trxID)
+		if err != nil {
+			return err
+		}
+		if trx.Status != models.TrxStatusPendingPayment {
+			return nil
+		}
+
+		if err := expireTransaction(tx, trx); err != nil {
+			return err
+		}
+		expired = true
+		return nil
+	})
+	return expired, err
+}
+
+// ExpireUnpaidOrders mengubah transaksi yang masih menunggu pembayaran melewati batas waktu
+// menjadi kadaluarsa dan mengembalikan stoknya. Kandidat diambil tanpa kunci, lalu setiap
+// transaksi diproses di DB transaction pendek tersendiri sehingga satu kegagalan (termasuk
+// deadlock atau lock wait timeout) tidak membatalkan transaksi lain. Status diperiksa ulang
+// setelah dikunci, sehingga beberapa instance aplikasi aman menjalankan scheduler bersamaan.
+func ExpireUnpaidOrders(cfg OrderExpiryConfig) (int, error) {
+	now := time.Now()
+	cutoff := now.Add(-cfg.Deadline)
+
+	var trxs []models.Transaction
+	if err := expirableOrders(config.DB.Model(&models.Transaction{}), now, cutoff).
+		Select("id", "kode_invoice").Order("id ASC").Limit(cfg.BatchSize).
+		Find(&trxs).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, trx := range trxs {
+		ok, err := expireOrder(trx.ID, now, cutoff)
+		if err != nil {
+			log.Printf("Gagal mengubah transaksi %s menjadi kadaluarsa: %v", trx.KodeInvoice, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+	return expired, nil
+}
+
+// StartOrderExpiryScheduler menjalankan ExpireUnpaidOrders secara berkala di background.
+// Fungsi yang dikembalikan dipakai untuk menghentikan scheduler.
+func StartOrderExpiryScheduler() func() {
+	cfg := LoadOrderExpiryConfig()
+	if !cfg.Enabled {
+		log.Println("Scheduler kadaluarsa transaksi dinonaktifkan")
+		return func() {}
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				count, err := ExpireUnpaidOrders(cfg)
+				if err != nil {
+					log.Printf("Scheduler kadaluarsa transaksi gagal: %v", err)
+				} else if count > 0 {
+					log.Printf("Scheduler kadaluarsa transaksi: %d transaksi kadaluarsa", count)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
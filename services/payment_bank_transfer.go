@@ -0,0 +1,39 @@
+package services
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// BankTransferProvider menerbitkan nomor virtual account untuk pembayaran transfer bank.
+// Konfirmasi pembayaran dikirim oleh bank melalui notifikasi yang ditandatangani dengan
+// PAYMENT_BANK_TRANSFER_SECRET, sehingga QueryStatus cukup membaca status yang tersimpan.
+type BankTransferProvider struct{}
+
+func (p *BankTransferProvider) Name() string {
+	return PaymentProviderBankTransfer
+}
+
+func (p *BankTransferProvider) CreateCharge(payment *models.Payment) (*ChargeResult, error) {
+	prefix := os.Getenv("PAYMENT_VA_PREFIX")
+	if prefix == "" {
+		prefix = "8808"
+	}
+
+	nomorVA := fmt.Sprintf("%s%010d", prefix, payment.ID)
+	return &ChargeResult{
+		NomorVA:        nomorVA,
+		Instruksi:      fmt.Sprintf("Transfer tepat Rp%d ke virtual account %s", payment.Jumlah, nomorVA),
+		KadaluarsaPada: chargeExpiry(payment),
+	}, nil
+}
+
+func (p *BankTransferProvider) QueryStatus(payment *models.Payment) (string, error) {
+	return payment.Status, nil
+}
+
+func (p *BankTransferProvider) VerifyCallback(payload []byte, signature string) bool {
+	return verifySignature(os.Getenv("PAYMENT_BANK_TRANSFER_SECRET"), payload, signature)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// Secret bawaan provider mock jika PAYMENT_MOCK_SECRET tidak diisi
+const defaultMockPaymentSecret = "mock-secret"
+
+// MockPaymentProvider adalah penyedia pembayaran in-process untuk pengujian lokal.
+// Pembayaran disimulasikan dengan Settle dan notifikasinya dikirim langsung ke
+// HandlePaymentWebhook tanpa akses jaringan.
+type MockPaymentProvider struct {
+	settled sync.Map
+}
+
+var mockPaymentProvider = &MockPaymentProvider{}
+
+func (p *MockPaymentProvider) Name() string {
+	return PaymentProviderMock
+}
+
+func (p *MockPaymentProvider) CreateCharge(payment *models.Payment) (*ChargeResult, error) {
+	return &ChargeResult{
+		Instruksi:      fmt.Sprintf("Simulasikan pembayaran melalui POST /api/v1/payments/mock/%s/settle", payment.Referensi),
+		KadaluarsaPada: chargeExpiry(payment),
+	}, nil
+}
+
+func (p *MockPaymentProvider) QueryStatus(payment *models.Payment) (string, error) {
+	if _, ok := p.settled.Load(payment.Referensi); ok {
+		return models.PaymentStatusPaid, nil
+	}
+	return payment.Status, nil
+}
+
+func (p *MockPaymentProvider) VerifyCallback(payload []byte, signature string) bool {
+	return verifySignature(MockPaymentSecret(), payload, signature)
+}
+
+// Settle menandai tagihan sebagai sudah dibayar di sisi provider mock
+func (p *MockPaymentProvider) Settle(referensi string) {
+	p.settled.Store(referensi, true)
+}
+
+// BuildNotification menyusun notifikasi bertanda tangan seperti yang dikirim penyedia ke webhook.
+// ID notifikasi dibuat deterministik sehingga pengiriman ulang akan terdeteksi sebagai duplikat.
+func (p *MockPaymentProvider) BuildNotification(payment *models.Payment, status string) ([]byte, string, error) {
+	payload, err := json.Marshal(PaymentNotificationPayload{
+		IDNotifikasi: fmt.Sprintf("MOCK-%s-%s", payment.Referensi, status),
+		Referensi:    payment.Referensi,
+		Status:       status,
+		Jumlah:       payment.Jumlah,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, SignPayload(MockPaymentSecret(), payload), nil
+}
+
+// MockPaymentSecret mengembalikan secret yang dipakai provider mock untuk menandatangani notifikasi
+func MockPaymentSecret() string {
+	if secret := os.Getenv("PAYMENT_MOCK_SECRET"); secret != "" {
+		return secret
+	}
+	return defaultMockPaymentSecret
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// Nama penyedia pembayaran, dipakai sebagai nilai method_bayar pada transaksi
+const (
+	PaymentProviderBankTransfer = "bank_transfer"
+	PaymentProviderMock         = "mock"
+)
+
+var ErrProviderPembayaranTidakDikenal = errors.New("metode pembayaran tidak dikenal")
+
+// ChargeResult adalah hasil pembuatan tagihan di penyedia pembayaran
+type ChargeResult struct {
+	NomorVA        string
+	Instruksi      string
+	KadaluarsaPada time.Time
+}
+
+// PaymentProvider adalah kontrak yang harus dipenuhi setiap penyedia pembayaran
+type PaymentProvider interface {
+	// Name mengembalikan nama penyedia yang juga dipakai sebagai method_bayar
+	Name() string
+	// CreateCharge membuat tagihan untuk payment yang sudah tersimpan
+	CreateCharge(payment *models.Payment) (*ChargeResult, error)
+	// QueryStatus menanyakan status terbaru tagihan ke penyedia
+	QueryStatus(payment *models.Payment) (string, error)
+	// VerifyCallback memverifikasi tanda tangan notifikasi yang dikirim penyedia
+	VerifyCallback(payload []byte, signature string) bool
+}
+
+var paymentProviders = map[string]PaymentProvider{
+	PaymentProviderBankTransfer: &BankTransferProvider{},
+	PaymentProviderMock:         mockPaymentProvider,
+}
+
+// mockPaymentEnabled mengecek apakah provider mock boleh dipakai (hanya untuk pengembangan lokal)
+func mockPaymentEnabled() bool {
+	return os.Getenv("PAYMENT_MOCK_ENABLED") == "true"
+}
+
+// GetPaymentProvider mengambil penyedia pembayaran berdasarkan nama
+func GetPaymentProvider(name string) (PaymentProvider, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == PaymentProviderMock && !mockPaymentEnabled() {
+		return nil, ErrProviderPembayaranTidakDikenal
+	}
+
+	provider, ok := paymentProviders[name]
+	if !ok {
+		return nil, ErrProviderPembayaranTidakDikenal
+	}
+	return provider, nil
+}
+
+// paymentExpiry membaca masa berlaku tagihan dari environment variable (default 24 jam)
+func paymentExpiry() time.Duration {
+	if v, err := time.ParseDuration(os.Getenv("PAYMENT_EXPIRY")); err == nil && v > 0 {
+		return v
+	}
+	return 24 * time.Hour
+}
+
+// chargeExpiry menentukan masa berlaku tagihan di provider. Batas yang sudah ditetapkan
+// CreatePayment dipakai agar tagihan tidak berlaku melewati batas pembayaran transaksi.
+func chargeExpiry(payment *models.Payment) time.Time {
+	if payment.KadaluarsaPada != nil {
+		return *payment.KadaluarsaPada
+	}
+	return time.Now().Add(paymentExpiry())
+}
+
+// SignPayload menghasilkan tanda tangan HMAC-SHA256 (hex) dari payload notifikasi
+func SignPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verifySignature membandingkan tanda tangan secara constant-time
+func verifySignature(secret string, payload []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	expected := SignPayload(secret, payload)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(signature)))
+}
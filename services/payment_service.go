@@ -0,0 +1,232 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPembayaranTidakDitemukan         = errors.New("pembayaran tidak ditemukan")
+	ErrTransaksiTidakMenungguPembayaran = errors.New("transaksi tidak dalam status menunggu pembayaran")
+)
+
+// generatePaymentReference membuat referensi pembayaran acak yang unik
+func generatePaymentReference() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "PAY-" + strings.ToUpper(hex.EncodeToString(buf)), nil
+}
+
+// CreatePayment membuat tagihan pembayaran untuk transaksi milik pembeli menggunakan provider
+// sesuai method_bayar. Tagihan yang masih berlaku akan dipakai ulang, dan masa berlakunya tidak
+// melewati batas pembayaran transaksi (ORDER_PAYMENT_DEADLINE sejak transaksi dibuat).
+func CreatePayment(trxID, userID uint) (*models.Payment, error) {
+	var trx models.Transaction
+	if err := config.DB.First(&trx, trxID).Error; err != nil || trx.IDUser != userID {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+	if trx.Status != models.TrxStatusPendingPayment {
+		return nil, ErrTransaksiTidakMenungguPembayaran
+	}
+
+	provider, err := GetPaymentProvider(trx.MethodBayar)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pakai ulang tagihan yang masih berlaku
+	var existing models.Payment
+	if err := config.DB.Where("id_trx = ? AND status = ? AND kadaluarsa_pada > ?",
+		trx.ID, models.PaymentStatusPending, time.Now()).
+		Order("id DESC").First(&existing).Error; err == nil {
+		return &existing, nil
+	}
+
+	referensi, err := generatePaymentReference()
+	if err != nil {
+		return nil, errors.New("gagal membuat referensi pembayaran")
+	}
+
+	// Tagihan tidak boleh berlaku melewati batas pembayaran transaksi, karena setelah itu
+	// transaksi dikadaluarsakan dan stoknya dikembalikan
+	now := time.Now()
+	batas := orderPaymentDeadline(&trx)
+	if !now.Before(batas) {
+		return nil, ErrTransaksiTidakMenungguPembayaran
+	}
+	kadaluarsa := now.Add(paymentExpiry())
+	if kadaluarsa.After(batas) {
+		kadaluarsa = batas
+	}
+
+	payment := models.Payment{
+		IDTrx:          trx.ID,
+		Provider:       provider.Name(),
+		Referensi:      referensi,
+		Jumlah:         trx.HargaTotal,
+		Status:         models.PaymentStatusPending,
+		KadaluarsaPada: &kadaluarsa,
+	}
+	if err := config.DB.Create(&payment).Error; err != nil {
+		return nil, errors.New("gagal menyimpan pembayaran")
+	}
+
+	result, err := provider.CreateCharge(&payment)
+	if err != nil {
+		config.DB.Model(&payment).Update("status", models.PaymentStatusFailed)
+		return nil, errors.New("gagal membuat tagihan pembayaran")
+	}
+
+	payment.NomorVA = result.NomorVA
+	payment.Instruksi = result.Instruksi
+	if result.KadaluarsaPada.Before(kadaluarsa) {
+		payment.KadaluarsaPada = &result.KadaluarsaPada
+	}
+	if err := config.DB.Model(&payment).Updates(map[string]interface{}{
+		"nomor_va":        payment.NomorVA,
+		"instruksi":       payment.Instruksi,
+		"kadaluarsa_pada": payment.KadaluarsaPada,
+	}).Error; err != nil {
+		return nil, errors.New("gagal menyimpan tagihan pembayaran")
+	}
+
+	return &payment, nil
+}
+
+// GetLatestPayment mengambil tagihan terakhir sebuah transaksi untuk pembeli atau admin,
+// sekaligus menyegarkan statusnya dari provider
+func GetLatestPayment(trxID, userID uint) (*models.Payment, error) {
+	var trx models.Transaction
+	if err := config.DB.First(&trx, trxID).Error; err != nil {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := pickActor(actor.TransactionRoles(config.DB, &trx), []string{AktorBuyer, AktorAdmin}); !ok {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+
+	var payment models.Payment
+	if err := config.DB.Where("id_trx = ?", trx.ID).Order("id DESC").First(&payment).Error; err != nil {
+		return nil, ErrPembayaranTidakDitemukan
+	}
+
+	if err := RefreshPaymentStatus(&payment); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// RefreshPaymentStatus menanyakan status tagihan ke provider dan menerapkannya jika sudah dibayar
+func RefreshPaymentStatus(payment *models.Payment) error {
+	if payment.Status != models.PaymentStatusPending {
+		return nil
+	}
+
+	provider, err := GetPaymentProvider(payment.Provider)
+	if err != nil {
+		return nil
+	}
+
+	status, err := provider.QueryStatus(payment)
+	if err != nil {
+		return errors.New("gagal menanyakan status pembayaran")
+	}
+	if status != models.PaymentStatusPaid {
+		return nil
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		return MarkPaymentPaid(tx, payment)
+	})
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarkPaymentPaid menandai tagihan sebagai lunas dan memindahkan transaksi dari menunggu
+// pembayaran ke dibayar di dalam DB transaction yang sedang berjalan
+func MarkPaymentPaid(tx *gorm.DB, payment *models.Payment) error {
+	var locked models.Payment
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&locked, payment.ID).Error; err != nil {
+		return ErrPembayaranTidakDitemukan
+	}
+	if locked.Status == models.PaymentStatusPaid {
+		*payment = locked
+		return nil
+	}
+
+	now := time.Now()
+	if err := tx.Model(&locked).Updates(map[string]interface{}{
+		"status":       models.PaymentStatusPaid,
+		"dibayar_pada": now,
+	}).Error; err != nil {
+		return err
+	}
+	locked.Status = models.PaymentStatusPaid
+	locked.DibayarPada = &now
+	*payment = locked
+
+	trx, err := LockTransaction(tx, payment.IDTrx)
+	if err != nil {
+		return err
+	}
+
+	// Pembayaran yang masuk setelah transaksi dibatalkan/kadaluarsa tetap dicatat untuk rekonsiliasi
+	if trx.Status != models.TrxStatusPendingPayment {
+		return nil
+	}
+	return ApplyTransactionStatus(tx, trx, models.TrxStatusPaid, AktorSystem, nil, "Pembayaran "+payment.Referensi)
+}
+
+// lockPendingPayments mengunci tagihan transaksi yang masih menunggu pembayaran. Proses yang
+// mengubah tagihan dan transaksi sekaligus memanggilnya sebelum LockTransaction, sama dengan urutan
+// MarkPaymentPaid (tagihan lalu transaksi), agar tidak saling deadlock dengan webhook pembayaran.
+func lockPendingPayments(tx *gorm.DB, trxID uint) error {
+	var payments []models.Payment
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id_trx = ? AND status = ?", trxID, models.PaymentStatusPending).
+		Order("id ASC").Find(&payments).Error
+}
+
+// SettleMockPayment mensimulasikan pembayaran pada provider mock. Notifikasi bertanda tangan
+// dikirim melalui jalur webhook yang sama dengan penyedia sungguhan.
+func SettleMockPayment(referensi string) (*models.Payment, error) {
+	if !mockPaymentEnabled() {
+		return nil, ErrProviderPembayaranTidakDikenal
+	}
+
+	var payment models.Payment
+	if err := config.DB.Where("referensi = ? AND provider = ?", referensi, PaymentProviderMock).
+		First(&payment).Error; err != nil {
+		return nil, ErrPembayaranTidakDitemukan
+	}
+
+	mockPaymentProvider.Settle(referensi)
+	payload, signature, err := mockPaymentProvider.BuildNotification(&payment, models.PaymentStatusPaid)
+	if err != nil {
+		return nil, errors.New("gagal membuat notifikasi pembayaran mock")
+	}
+	if _, err := HandlePaymentWebhook(PaymentProviderMock, payload, signature); err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.First(&payment, payment.ID).Error; err != nil {
+		return nil, ErrPembayaranTidakDitemukan
+	}
+	return &payment, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSignatureTidakValid         = errors.New("signature notifikasi tidak valid")
+	ErrPayloadNotifikasiTidakValid = errors.New("payload notifikasi tidak valid")
+	ErrJumlahPembayaranTidakSesuai = errors.New("jumlah pembayaran tidak sesuai dengan tagihan")
+)
+
+// PaymentNotificationPayload adalah isi notifikasi yang dikirim penyedia pembayaran ke webhook
+type PaymentNotificationPayload struct {
+	IDNotifikasi string `json:"id_notifikasi"`
+	Referensi    string `json:"referensi"`
+	Status       string `json:"status"`
+	Jumlah       int    `json:"jumlah"`
+}
+
+// notificationDedupKey menyusun kunci unik notifikasi per penyedia
+func notificationDedupKey(provider, idNotifikasi string) string {
+	return provider + ":" + idNotifikasi
+}
+
+// finishNotification memperbarui hasil pemrosesan notifikasi pada log
+func finishNotification(notif *models.PaymentNotification, status, pesan string) {
+	notif.Status = status
+	notif.Pesan = pesan
+	config.DB.Model(notif).Updates(map[string]interface{}{
+		"status": status,
+		"pesan":  pesan,
+	})
+}
+
+// HandlePaymentWebhook memproses notifikasi dari penyedia pembayaran. Payload mentah selalu
+// dicatat terlebih dahulu, lalu signature diverifikasi dan status pembayaran beserta transaksi
+// diperbarui dalam satu DB transaction. Notifikasi yang sudah pernah diproses dikembalikan
+// dengan status duplicate tanpa mengubah data.
+func HandlePaymentWebhook(providerName string, payload []byte, signature string) (*models.PaymentNotification, error) {
+	notif := models.PaymentNotification{
+		Provider:  strings.ToLower(strings.TrimSpace(providerName)),
+		Payload:   string(payload),
+		Signature: signature,
+		Status:    models.NotificationStatusReceived,
+	}
+	if err := config.DB.Create(&notif).Error; err != nil {
+		return nil, errors.New("gagal mencatat notifikasi pembayaran")
+	}
+
+	provider, err := GetPaymentProvider(notif.Provider)
+	if err != nil {
+		finishNotification(&notif, models.NotificationStatusRejected, err.Error())
+		return &notif, err
+	}
+
+	if !provider.VerifyCallback(payload, signature) {
+		finishNotification(&notif, models.NotificationStatusRejected, ErrSignatureTidakValid.Error())
+		return &notif, ErrSignatureTidakValid
+	}
+	notif.SignatureValid = true
+	config.DB.Model(&notif).Update("signature_valid", true)
+
+	var body PaymentNotificationPayload
+	if err := json.Unmarshal(payload, &body); err != nil || body.IDNotifikasi == "" || body.Referensi == "" {
+		finishNotification(&notif, models.NotificationStatusRejected, ErrPayloadNotifikasiTidakValid.Error())
+		return &notif, ErrPayloadNotifikasiTidakValid
+	}
+
+	notif.IDNotifikasi = body.IDNotifikasi
+	notif.Referensi = body.Referensi
+	notif.StatusPayload = body.Status
+	config.DB.Model(&notif).Updates(map[string]interface{}{
+		"id_notifikasi":  notif.IDNotifikasi,
+		"referensi":      notif.Referensi,
+		"status_payload": notif.StatusPayload,
+	})
+
+	duplicate := false
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock tagihan agar notifikasi paralel untuk tagihan yang sama diproses bergantian
+		var payment models.Payment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("referensi = ? AND provider = ?", body.Referensi, provider.Name()).
+			First(&payment).Error; err != nil {
+			return ErrPembayaranTidakDitemukan
+		}
+
+		kunci := notificationDedupKey(provider.Name(), body.IDNotifikasi)
+		var count int64
+		tx.Model(&models.PaymentNotification{}).Where("kunci_dedup = ?", kunci).Count(&count)
+		if count > 0 {
+			duplicate = true
+			return nil
+		}
+
+		// Kunci dedup disimpan di DB transaction yang sama dengan perubahan status,
+		// sehingga notifikasi yang gagal diproses bisa dikirim ulang oleh penyedia
+		if err := tx.Model(&notif).Updates(map[string]interface{}{
+			"kunci_dedup": kunci,
+			"id_payment":  payment.ID,
+		}).Error; err != nil {
+			return err
+		}
+		notif.KunciDedup = &kunci
+		notif.IDPayment = &payment.ID
+
+		switch body.Status {
+		case models.PaymentStatusPaid:
+			if body.Jumlah != payment.Jumlah {
+				return ErrJumlahPembayaranTidakSesuai
+			}
+			return MarkPaymentPaid(tx, &payment)
+		case models.PaymentStatusFailed, models.PaymentStatusExpired:
+			return tx.Model(&models.Payment{}).
+				Where("id = ? AND status = ?", payment.ID, models.PaymentStatusPending).
+				Update("status", body.Status).Error
+		default:
+			return ErrPayloadNotifikasiTidakValid
+		}
+	})
+
+	switch {
+	case err != nil:
+		status := models.NotificationStatusFailed
+		if errors.Is(err, ErrPembayaranTidakDitemukan) || errors.Is(err, ErrPayloadNotifikasiTidakValid) ||
+			errors.Is(err, ErrJumlahPembayaranTidakSesuai) {
+			status = models.NotificationStatusRejected
+		}
+		notif.KunciDedup = nil
+		notif.IDPayment = nil
+		finishNotification(&notif, status, err.Error())
+		return &notif, err
+	case duplicate:
+		finishNotification(&notif, models.NotificationStatusDuplicate, "notifikasi sudah pernah diproses")
+	default:
+		finishNotification(&notif, models.NotificationStatusProcessed, fmt.Sprintf("status pembayaran %s", body.Status))
+	}
+
+	return &notif, nil
+}
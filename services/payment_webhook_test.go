@@ -0,0 +1,144 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// createMockPayment membuat transaksi dengan provider mock beserta tagihannya
+func createMockPayment(t *testing.T) (*models.Transaction, *models.Payment) {
+	t.Helper()
+	t.Setenv("PAYMENT_MOCK_ENABLED", "true")
+
+	_, produk := createTestProduct(t, 10, 15000)
+	user, alamat := createTestUser(t)
+	trx, err := CreateTransaction(user.ID, CheckoutRequest{
+		MethodBayar:      PaymentProviderMock,
+		AlamatPengiriman: alamat.ID,
+		DetailTransaksi:  []CheckoutItem{{ProductID: produk.ID, Kuantitas: 2}},
+	})
+	if err != nil {
+		t.Fatalf("gagal membuat transaksi: %v", err)
+	}
+	payment, err := CreatePayment(trx.ID, user.ID)
+	if err != nil {
+		t.Fatalf("gagal membuat tagihan: %v", err)
+	}
+	return trx, payment
+}
+
+// reloadPayment membaca ulang status tagihan dan transaksinya dari database
+func reloadPayment(t *testing.T, payment *models.Payment) (*models.Payment, *models.Transaction) {
+	t.Helper()
+	var p models.Payment
+	if err := config.DB.First(&p, payment.ID).Error; err != nil {
+		t.Fatalf("gagal membaca tagihan: %v", err)
+	}
+	var trx models.Transaction
+	if err := config.DB.Preload("SubTransaksi").First(&trx, p.IDTrx).Error; err != nil {
+		t.Fatalf("gagal membaca transaksi: %v", err)
+	}
+	return &p, &trx
+}
+
+func TestPaymentWebhookRejectsInvalidSignature(t *testing.T) {
+	requireDB(t)
+	_, payment := createMockPayment(t)
+
+	payload, _, err := mockPaymentProvider.BuildNotification(payment, models.PaymentStatusPaid)
+	if err != nil {
+		t.Fatalf("gagal membuat notifikasi: %v", err)
+	}
+	signature := SignPayload("secret-lain", payload)
+
+	notif, err := HandlePaymentWebhook(PaymentProviderMock, payload, signature)
+	if !errors.Is(err, ErrSignatureTidakValid) {
+		t.Fatalf("error = %v, seharusnya %v", err, ErrSignatureTidakValid)
+	}
+	if notif.Status != models.NotificationStatusRejected || notif.SignatureValid {
+		t.Errorf("notifikasi status=%s signature_valid=%v, seharusnya rejected tanpa signature valid",
+			notif.Status, notif.SignatureValid)
+	}
+
+	p, trx := reloadPayment(t, payment)
+	if p.Status != models.PaymentStatusPending {
+		t.Errorf("status tagihan = %s, seharusnya %s", p.Status, models.PaymentStatusPending)
+	}
+	if trx.Status != models.TrxStatusPendingPayment {
+		t.Errorf("status transaksi = %s, seharusnya %s", trx.Status, models.TrxStatusPendingPayment)
+	}
+}
+
+func TestPaymentWebhookPaidMovesOrderForward(t *testing.T) {
+	requireDB(t)
+	_, payment := createMockPayment(t)
+
+	payload, signature, err := mockPaymentProvider.BuildNotification(payment, models.PaymentStatusPaid)
+	if err != nil {
+		t.Fatalf("gagal membuat notifikasi: %v", err)
+	}
+	notif, err := HandlePaymentWebhook(PaymentProviderMock, payload, signature)
+	if err != nil {
+		t.Fatalf("webhook gagal: %v", err)
+	}
+	if notif.Status != models.NotificationStatusProcessed {
+		t.Errorf("status notifikasi = %s, seharusnya %s", notif.Status, models.NotificationStatusProcessed)
+	}
+
+	p, trx := reloadPayment(t, payment)
+	if p.Status != models.PaymentStatusPaid || p.DibayarPada == nil {
+		t.Errorf("tagihan status=%s dibayar_pada=%v, seharusnya paid", p.Status, p.DibayarPada)
+	}
+	if trx.Status != models.TrxStatusPaid {
+		t.Errorf("status transaksi = %s, seharusnya %s", trx.Status, models.TrxStatusPaid)
+	}
+	for _, sub := range trx.SubTransaksi {
+		if sub.Status != models.TrxStatusPaid {
+			t.Errorf("status sub-transaksi %s = %s, seharusnya %s", sub.KodeInvoice, sub.Status, models.TrxStatusPaid)
+		}
+	}
+}
+
+func TestPaymentWebhookDuplicateAppliedOnce(t *testing.T) {
+	requireDB(t)
+	trx, payment := createMockPayment(t)
+
+	payload, signature, err := mockPaymentProvider.BuildNotification(payment, models.PaymentStatusPaid)
+	if err != nil {
+		t.Fatalf("gagal membuat notifikasi: %v", err)
+	}
+
+	first, err := HandlePaymentWebhook(PaymentProviderMock, payload, signature)
+	if err != nil {
+		t.Fatalf("webhook pertama gagal: %v", err)
+	}
+	second, err := HandlePaymentWebhook(PaymentProviderMock, payload, signature)
+	if err != nil {
+		t.Fatalf("webhook kedua gagal: %v", err)
+	}
+	if first.Status != models.NotificationStatusProcessed {
+		t.Errorf("status notifikasi pertama = %s, seharusnya %s", first.Status, models.NotificationStatusProcessed)
+	}
+	if second.Status != models.NotificationStatusDuplicate {
+		t.Errorf("status notifikasi kedua = %s, seharusnya %s", second.Status, models.NotificationStatusDuplicate)
+	}
+
+	var paidCount int64
+	config.DB.Model(&models.TransactionStatusHistory{}).
+		Where("id_trx = ? AND id_sub_trx IS NULL AND status_ke = ?", trx.ID, models.TrxStatusPaid).
+		Count(&paidCount)
+	if paidCount != 1 {
+		t.Errorf("riwayat status paid = %d, seharusnya 1", paidCount)
+	}
+
+	var processed int64
+	config.DB.Model(&models.PaymentNotification{}).
+		Where("id_payment = ? AND status = ?", payment.ID, models.NotificationStatusProcessed).
+		Count(&processed)
+	if processed != 1 {
+		t.Errorf("notifikasi yang diproses = %d, seharusnya 1", processed)
+	}
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+)
+
+// Actor adalah user yang sedang login beserta perannya. Semua pengecekan kepemilikan data
+// (pembeli, penjual, admin) sebaiknya dilakukan melalui method Actor agar aturannya seragam
+// di semua controller.
+type Actor struct {
+	UserID  uint
+	IsAdmin bool
+	TokoID  uint // 0 jika user tidak memiliki toko
+}
+
+// ResolveActor memuat peran user berdasarkan ID dari token
+func ResolveActor(userID uint) (*Actor, error) {
+	return resolveActor(config.DB, userID)
+}
+
+func resolveActor(db *gorm.DB, userID uint) (*Actor, error) {
+	var user models.User
+	if err := db.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+
+	actor := &Actor{UserID: user.ID, IsAdmin: user.IsAdmin}
+
+	var toko models.Toko
+	if err := db.Where("id_user = ?", user.ID).First(&toko).Error; err == nil {
+		actor.TokoID = toko.ID
+	}
+
+	return actor, nil
+}
+
+// ScopeTransactions membatasi query transaksi: pembeli melihat transaksinya sendiri, penjual juga
+// melihat transaksi yang berisi produk tokonya, admin melihat semua
+func (a *Actor) ScopeTransactions(db *gorm.DB) *gorm.DB {
+	if a.IsAdmin {
+		return db
+	}
+	if a.TokoID != 0 {
+		return db.Where("transactions.id_user = ? OR transactions.id IN (?)", a.UserID,
+			config.DB.Model(&models.DetailTransaction{}).Select("id_trx").Where("id_toko = ?", a.TokoID))
+	}
+	return db.Where("transactions.id_user = ?", a.UserID)
+}
+
+// TransactionRoles menentukan peran actor terhadap sebuah transaksi
+func (a *Actor) TransactionRoles(db *gorm.DB, trx *models.Transaction) []string {
+	var roles []string
+	if trx.IDUser == a.UserID {
+		roles = append(roles, AktorBuyer)
+	}
+	if a.IsAdmin {
+		roles = append(roles, AktorAdmin)
+	}
+
+	// Actor dianggap seller jika tokonya memiliki item di transaksi ini
+	if a.TokoID != 0 {
+		var count int64
+		db.Model(&models.DetailTransaction{}).
+			Where("id_trx = ? AND id_toko = ?", trx.ID, a.TokoID).
+			Count(&count)
+		if count > 0 {
+			roles = append(roles, AktorSeller)
+		}
+	}
+
+	return roles
+}
+
+// CanViewTransaction mengecek apakah actor boleh melihat transaksi
+func (a *Actor) CanViewTransaction(db *gorm.DB, trx *models.Transaction) bool {
+	return len(a.TransactionRoles(db, trx)) > 0
+}
+
+// VisibleDetails menyaring detail transaksi yang boleh dilihat actor. Penjual yang bukan pembeli
+// hanya melihat item dari tokonya sendiri.
+func (a *Actor) VisibleDetails(trx *models.Transaction) []models.DetailTransaction {
+	if a.IsAdmin || trx.IDUser == a.UserID {
+		return trx.DetailTransaksi
+	}
+
+	details := []models.DetailTransaction{}
+	for _, detail := range trx.DetailTransaksi {
+		if detail.IDToko == a.TokoID {
+			details = append(details, detail)
+		}
+	}
+	return details
+}
+
+// VisibleSubTransactions menyaring sub-transaksi yang boleh dilihat actor
+func (a *Actor) VisibleSubTransactions(trx *models.Transaction) []models.SubTransaction {
+	if a.IsAdmin || trx.IDUser == a.UserID {
+		return trx.SubTransaksi
+	}
+
+	subs := []models.SubTransaction{}
+	for _, sub := range trx.SubTransaksi {
+		if sub.IDToko == a.TokoID {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// ProjectTransaction menyesuaikan transaksi dengan yang boleh dilihat actor. Penjual yang bukan
+// pembeli hanya melihat item dan sub-transaksi tokonya, dengan total, diskon, refund dan voucher
+// yang dihitung dari sub-transaksi tersebut, bukan dari seluruh transaksi.
+func (a *Actor) ProjectTransaction(trx *models.Transaction) {
+	if a.IsAdmin || trx.IDUser == a.UserID {
+		return
+	}
+
+	trx.DetailTransaksi = a.VisibleDetails(trx)
+	trx.SubTransaksi = a.VisibleSubTransactions(trx)
+
+	hargaTotal, diskon, totalRefund := 0, 0, 0
+	for _, sub := range trx.SubTransaksi {
+		hargaTotal += sub.Subtotal + sub.OngkosKirim - sub.Diskon
+		diskon += sub.Diskon
+		totalRefund += sub.TotalRefund
+	}
+	// Transaksi lama tanpa sub-transaksi dihitung dari detailnya
+	if len(trx.SubTransaksi) == 0 {
+		for _, detail := range trx.DetailTransaksi {
+			hargaTotal += detail.HargaTotal - detail.Diskon
+			diskon += detail.Diskon
+		}
+	}
+	trx.HargaTotal = hargaTotal
+	trx.Diskon = diskon
+	trx.TotalRefund = totalRefund
+	// Voucher yang tidak memotong pesanan toko ini tidak ditampilkan
+	if diskon == 0 {
+		trx.IDVoucher = nil
+		trx.KodeVoucher = ""
+	}
+}
+
+// OwnsStore mengecek apakah actor adalah pemilik toko
+func (a *Actor) OwnsStore(tokoID uint) bool {
+	return a.TokoID != 0 && a.TokoID == tokoID
+}
+
+// CanManageProduct mengecek apakah actor boleh mengubah atau menghapus produk
+func (a *Actor) CanManageProduct(produk *models.Produk) bool {
+	return a.OwnsStore(produk.IDToko)
+}
+
+// CanManageVoucher mengecek apakah actor boleh mengubah atau menghapus voucher. Voucher
+// platform hanya dapat dikelola admin.
+func (a *Actor) CanManageVoucher(voucher *models.Voucher) bool {
+	if a.IsAdmin {
+		return true
+	}
+	return voucher.IDToko != nil && a.OwnsStore(*voucher.IDToko)
+}
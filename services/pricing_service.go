@@ -0,0 +1,26 @@
+package services
+
+import "github.com/habbazettt/evermos-service-go/models"
+
+// Tier harga yang diterapkan pada detail transaksi
+const (
+	TierKonsumen = "konsumen"
+	TierReseller = "reseller"
+)
+
+// PriceTierForUser menentukan tier harga untuk pembeli
+func PriceTierForUser(user *models.User) string {
+	if user.IsVerifiedReseller() {
+		return TierReseller
+	}
+	return TierKonsumen
+}
+
+// UnitPrice mengembalikan harga satuan produk untuk tier yang diminta beserta tier yang benar-benar
+// dipakai. Produk tanpa harga reseller (HargaReseller 0) tetap dijual dengan harga konsumen.
+func UnitPrice(produk *models.Produk, tier string) (int, string) {
+	if tier == TierReseller && produk.HargaReseller > 0 {
+		return produk.HargaReseller, TierReseller
+	}
+	return produk.HargaKonsumen, TierKonsumen
+}
@@ -0,0 +1,282 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrProdukSudahDiarsipkan = errors.New("produk sudah diarsipkan")
+	ErrProdukTidakDiarsipkan = errors.New("produk tidak sedang diarsipkan")
+)
+
+// ProductPurgeConfig mengatur penghapusan permanen produk yang diarsipkan terlalu lama
+type ProductPurgeConfig struct {
+	Enabled   bool
+	Retention time.Duration // lama produk disimpan di arsip sebelum dihapus permanen
+	Interval  time.Duration // jeda antar pengecekan
+	BatchSize int           // jumlah produk maksimal per pengecekan
+}
+
+// LoadProductPurgeConfig membaca konfigurasi purge dari environment variable. Scheduler purge
+// hanya berjalan jika PRODUCT_PURGE_ENABLED bernilai true karena datanya tidak bisa dipulihkan.
+func LoadProductPurgeConfig() ProductPurgeConfig {
+	cfg := ProductPurgeConfig{
+		Enabled:   os.Getenv("PRODUCT_PURGE_ENABLED") == "true",
+		Retention: 90 * 24 * time.Hour,
+		Interval:  24 * time.Hour,
+		BatchSize: 100,
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("PRODUCT_ARCHIVE_RETENTION")); err == nil && v > 0 {
+		cfg.Retention = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("PRODUCT_PURGE_INTERVAL")); err == nil && v > 0 {
+		cfg.Interval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("PRODUCT_PURGE_BATCH_SIZE")); err == nil && v > 0 {
+		cfg.BatchSize = v
+	}
+
+	return cfg
+}
+
+// lockManagedProductUnscoped mengunci produk termasuk yang diarsipkan dan memastikan user
+// boleh mengubahnya
+func lockManagedProductUnscoped(tx *gorm.DB, userID, produkID uint) (*models.Produk, error) {
+	var produk models.Produk
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&produk, produkID).Error; err != nil {
+		return nil, ErrProdukTidakDitemukan
+	}
+
+	actor, err := resolveActor(tx, userID)
+	if err != nil || !actor.CanManageProduct(&produk) {
+		return nil, ErrAksesProdukDitolak
+	}
+	return &produk, nil
+}
+
+// ArchiveProduct mengarsipkan produk toko user dengan soft delete. Foto, varian dan stok tetap
+// disimpan sehingga produk bisa dipulihkan dengan UnarchiveProduct.
+func ArchiveProduct(userID, produkID uint) (*models.Produk, error) {
+	var produk *models.Produk
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		produk, err = lockManagedProductUnscoped(tx, userID, produkID)
+		if err != nil {
+			return err
+		}
+		if produk.DeletedAt.Valid {
+			return ErrProdukSudahDiarsipkan
+		}
+		if err := tx.Delete(produk).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().First(produk, produk.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return produk, nil
+}
+
+// UnarchiveProduct memulihkan produk yang diarsipkan sehingga kembali tampil di katalog
+func UnarchiveProduct(userID, produkID uint) (*models.Produk, error) {
+	var produk *models.Produk
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		produk, err = lockManagedProductUnscoped(tx, userID, produkID)
+		if err != nil {
+			return err
+		}
+		if !produk.DeletedAt.Valid {
+			return ErrProdukTidakDiarsipkan
+		}
+		if err := tx.Unscoped().Model(produk).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.First(produk, produk.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return produk, nil
+}
+
+// GetArchivedProducts mengambil produk toko user yang sedang diarsipkan, terbaru lebih dulu
+func GetArchivedProducts(userID uint, page, limit int) ([]models.Produk, int64, int, error) {
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if actor.TokoID == 0 {
+		return nil, 0, 0, ErrAksesProdukDitolak
+	}
+
+	query := config.DB.Unscoped().Model(&models.Produk{}).
+		Where("id_toko = ? AND deleted_at IS NOT NULL", actor.TokoID)
+
+	var total int64
+	query.Count(&total)
+
+	produkList := []models.Produk{}
+	offset := (page - 1) * limit
+	if err := query.Preload("FotoProduk").Order("deleted_at DESC").
+		Limit(limit).Offset(offset).Find(&produkList).Error; err != nil {
+		return nil, 0, 0, errors.New("gagal mengambil produk yang diarsipkan")
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	return produkList, total, totalPages, nil
+}
+
+// FindArchivedProductForUser memuat produk yang diarsipkan agar tetap bisa dibuka dari riwayat
+// pesanan. Produk hanya ditampilkan untuk admin, pemilik toko, dan pembeli yang pernah memesannya.
+func FindArchivedProductForUser(userID, produkID uint) (*models.Produk, error) {
+	var produk models.Produk
+	err := config.DB.Unscoped().Preload("FotoProduk").
+		Preload("Opsi", func(db *gorm.DB) *gorm.DB { return db.Order("urutan ASC") }).
+		Preload("Opsi.Nilai", func(db *gorm.DB) *gorm.DB { return db.Order("urutan ASC") }).
+		Preload("Varian.Nilai").
+		Where("deleted_at IS NOT NULL").
+		First(&produk, produkID).Error
+	if err != nil {
+		return nil, ErrProdukTidakDitemukan
+	}
+
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.IsAdmin || actor.CanManageProduct(&produk) {
+		return &produk, nil
+	}
+
+	var count int64
+	config.DB.Model(&models.DetailTransaction{}).
+		Joins("JOIN log_produks ON log_produks.id = detail_transactions.id_log_produk").
+		Joins("JOIN transactions ON transactions.id = detail_transactions.id_trx").
+		Where("log_produks.id_produk = ? AND transactions.id_user = ?", produk.ID, actor.UserID).
+		Count(&count)
+	if count == 0 {
+		// Produk arsip disembunyikan seperti produk yang tidak ada
+		return nil, ErrProdukTidakDitemukan
+	}
+	return &produk, nil
+}
+
+// purgeProduct menghapus permanen satu produk yang diarsipkan beserta foto, varian dan item
+// keranjangnya. Snapshot LogProduk, ledger stok dan notifikasi tetap disimpan untuk riwayat.
+// URL foto dikembalikan agar dihapus dari Cloudinary setelah transaksi berhasil.
+func purgeProduct(tx *gorm.DB, produkID uint, cutoff time.Time) ([]string, error) {
+	var produk models.Produk
+	// Produk yang sudah dipulihkan atau dipurge proses lain dilewati
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Preload("FotoProduk").
+		First(&produk, produkID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if err := tx.Where("id_produk = ?", produk.ID).Delete(&models.CartItem{}).Error; err != nil {
+		return nil, err
+	}
+	if err := DeleteProductVariants(tx, produk.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Where("id_produk = ?", produk.ID).Delete(&models.FotoProduk{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Unscoped().Delete(&produk).Error; err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(produk.FotoProduk))
+	for _, foto := range produk.FotoProduk {
+		urls = append(urls, foto.URL)
+	}
+	return urls, nil
+}
+
+// PurgeArchivedProducts menghapus permanen produk yang diarsipkan lebih lama dari retention.
+// Setiap produk dihapus di transaksi tersendiri agar satu kegagalan tidak membatalkan produk
+// lain. Pesanan lama tetap bisa dibuka karena detail transaksi merujuk snapshot LogProduk.
+func PurgeArchivedProducts(retention time.Duration, batchSize int) (int, error) {
+	cutoff := time.Now().Add(-retention)
+
+	var produkIDs []uint
+	if err := config.DB.Unscoped().Model(&models.Produk{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at ASC").Limit(batchSize).
+		Pluck("id", &produkIDs).Error; err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, produkID := range produkIDs {
+		var urls []string
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			urls, err = purgeProduct(tx, produkID, cutoff)
+			return err
+		})
+		if err != nil {
+			log.Printf("Gagal menghapus permanen produk %d: %v", produkID, err)
+			continue
+		}
+		if urls == nil {
+			continue
+		}
+		for _, url := range urls {
+			if err := DeleteFromCloudinary(url); err != nil {
+				log.Printf("Gagal menghapus foto produk %d dari Cloudinary: %v", produkID, err)
+			}
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// StartProductPurgeScheduler menjalankan PurgeArchivedProducts secara berkala di background.
+// Fungsi yang dikembalikan dipakai untuk menghentikan scheduler.
+func StartProductPurgeScheduler() func() {
+	cfg := LoadProductPurgeConfig()
+	if !cfg.Enabled {
+		log.Println("Scheduler purge produk arsip dinonaktifkan")
+		return func() {}
+	}
+
+	ticker := time.NewTicker(cfg.Interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				count, err := PurgeArchivedProducts(cfg.Retention, cfg.BatchSize)
+				if err != nil {
+					log.Printf("Scheduler purge produk arsip gagal: %v", err)
+				} else if count > 0 {
+					log.Printf("Scheduler purge produk arsip: %d produk dihapus permanen", count)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"github.com/habbazettt/evermos-service-go/utils"
+	"gorm.io/gorm"
+)
+
+// ProductExport adalah file katalog produk hasil ekspor
+type ProductExport struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// optionalInt menulis harga varian yang mengikuti harga produk sebagai sel kosong
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
+
+// productSheetRows menyusun katalog toko dengan kolom ProductSheetColumns. Produk bervarian
+// ditulis sebagai satu baris produk tanpa stok diikuti satu baris per varian, sehingga file
+// hasil ekspor bisa diubah lalu diimpor kembali.
+func productSheetRows(produkList []models.Produk) [][]string {
+	rows := [][]string{ProductSheetColumns}
+	for _, p := range produkList {
+		stok := strconv.Itoa(p.Stok)
+		if len(p.Varian) > 0 {
+			stok = ""
+		}
+		rows = append(rows, []string{
+			p.Slug, "", p.NamaProduk, "", p.Deskripsi, strconv.Itoa(int(p.IDCategory)),
+			strconv.Itoa(p.HargaReseller), strconv.Itoa(p.HargaKonsumen), stok,
+			strconv.Itoa(p.StokMinimum), strconv.Itoa(p.Berat),
+		})
+		for _, v := range p.Varian {
+			rows = append(rows, []string{
+				p.Slug, v.SKU, "", v.Nama, "", "",
+				optionalInt(v.HargaReseller), optionalInt(v.HargaKonsumen), strconv.Itoa(v.Stok),
+				"", "",
+			})
+		}
+	}
+	return rows
+}
+
+// ExportProducts mengekspor katalog produk toko user sebagai file CSV atau XLSX dengan format
+// yang sama dengan file impor
+func ExportProducts(userID uint, format string) (*ProductExport, error) {
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.TokoID == 0 {
+		return nil, ErrAksesProdukDitolak
+	}
+
+	var produkList []models.Produk
+	if err := config.DB.Preload("Varian", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).Where("id_toko = ?", actor.TokoID).Order("id ASC").Find(&produkList).Error; err != nil {
+		return nil, err
+	}
+	rows := productSheetRows(produkList)
+
+	export := &ProductExport{
+		Filename: fmt.Sprintf("produk-toko-%d-%s.%s", actor.TokoID, time.Now().Format("20060102"), format),
+	}
+	var buf bytes.Buffer
+	switch format {
+	case SheetFormatCSV:
+		export.ContentType = "text/csv; charset=utf-8"
+		// BOM agar Excel membaca huruf non-ASCII dengan benar
+		buf.Write(utf8BOM)
+		w := csv.NewWriter(&buf)
+		if err := w.WriteAll(rows); err != nil {
+			return nil, err
+		}
+	case SheetFormatXLSX:
+		export.ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		if err := utils.WriteXLSX(&buf, "Produk", rows); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrFormatFileTidakDikenal
+	}
+	export.Data = buf.Bytes()
+	return export, nil
+}
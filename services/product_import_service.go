@@ -0,0 +1,615 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"github.com/habbazettt/evermos-service-go/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Format file impor dan ekspor katalog produk
+const (
+	SheetFormatCSV  = "csv"
+	SheetFormatXLSX = "xlsx"
+)
+
+// Aksi yang dilakukan impor untuk setiap baris
+const (
+	ImportAksiCreate       = "create"
+	ImportAksiUpdate       = "update"
+	ImportAksiUpdateVarian = "update_varian"
+)
+
+const (
+	importDefaultMaxRows       = 1000
+	importDefaultBatchSize     = 100
+	importReferensiMaxLength   = 64
+	importPesanSKUTidakDikenal = "SKU tidak ditemukan; varian baru dibuat melalui PUT /product/{id}/variants"
+)
+
+var (
+	ErrFormatFileTidakDikenal  = errors.New("format file harus csv atau xlsx")
+	ErrFileImporTidakValid     = errors.New("file impor tidak dapat dibaca")
+	ErrFileImporKosong         = errors.New("file impor tidak berisi baris produk")
+	ErrKolomImporTidakLengkap  = errors.New("header file harus memuat kolom slug, sku atau nama_produk")
+	ErrBarisImporTerlaluBanyak = errors.New("jumlah baris file impor melebihi batas")
+
+	errImporDibatalkan      = errors.New("impor dibatalkan")
+	errBarisImporTidakValid = errors.New("baris impor tidak valid")
+)
+
+// utf8BOM ditambahkan Excel di awal file CSV UTF-8
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ProductSheetColumns adalah kolom file impor dan ekspor katalog produk. Baris tanpa sku adalah
+// produk yang dicocokkan berdasarkan slug; baris dengan sku mengubah harga dan stok varian.
+var ProductSheetColumns = []string{
+	"slug", "sku", "nama_produk", "nama_varian", "deskripsi", "id_category",
+	"harga_reseller", "harga_konsumen", "stok", "stok_minimum", "berat",
+}
+
+// ImportRowResult adalah hasil impor satu baris file
+type ImportRowResult struct {
+	Baris      int      `json:"baris"`
+	Slug       string   `json:"slug"`
+	SKU        string   `json:"sku,omitempty"`
+	Aksi       string   `json:"aksi,omitempty"`
+	IDProduk   uint     `json:"id_produk,omitempty"`
+	Diterapkan bool     `json:"diterapkan"`
+	Errors     []string `json:"errors,omitempty"`
+}
+
+// ImportResult adalah laporan impor katalog. Perubahan hanya disimpan jika bukan dry run dan
+// seluruh baris valid; jika ada satu baris gagal validasi, tidak ada perubahan yang disimpan.
+// Baris disimpan per batch, sehingga jika sebuah batch gagal karena data berubah bersamaan,
+// batch sebelumnya tetap tersimpan dan JumlahDiterapkan menunjukkan jumlah barisnya.
+type ImportResult struct {
+	DryRun           bool              `json:"dry_run"`
+	Diterapkan       bool              `json:"diterapkan"`
+	JumlahBaris      int               `json:"jumlah_baris"`
+	JumlahDiterapkan int               `json:"jumlah_diterapkan"`
+	Dibuat           int               `json:"dibuat"`
+	Diperbarui       int               `json:"diperbarui"`
+	Gagal            int               `json:"gagal"`
+	Baris            []ImportRowResult `json:"baris"`
+}
+
+// SheetFormatFromFilename menentukan format file dari ekstensinya
+func SheetFormatFromFilename(filename string) (string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		return SheetFormatCSV, nil
+	case ".xlsx":
+		return SheetFormatXLSX, nil
+	default:
+		return "", ErrFormatFileTidakDikenal
+	}
+}
+
+// importMaxRows membaca batas jumlah baris per impor dari environment variable (default 1000)
+func importMaxRows() int {
+	if v, err := strconv.Atoi(os.Getenv("IMPORT_MAX_ROWS")); err == nil && v > 0 {
+		return v
+	}
+	return importDefaultMaxRows
+}
+
+// importBatchSize membaca jumlah baris yang disimpan per DB transaction dari environment variable
+// (default 100), agar impor besar tidak mengunci banyak produk sekaligus
+func importBatchSize() int {
+	if v, err := strconv.Atoi(os.Getenv("IMPORT_BATCH_SIZE")); err == nil && v > 0 {
+		return v
+	}
+	return importDefaultBatchSize
+}
+
+// readSheet membaca isi file CSV atau XLSX menjadi baris-baris sel
+func readSheet(format string, data []byte) ([][]string, error) {
+	switch format {
+	case SheetFormatXLSX:
+		rows, err := utils.ReadXLSX(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFileImporTidakValid, err)
+		}
+		return rows, nil
+	case SheetFormatCSV:
+		data = bytes.TrimPrefix(data, utf8BOM)
+		reader := csv.NewReader(bytes.NewReader(data))
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		// Excel dengan locale Indonesia menyimpan CSV dengan pemisah titik koma
+		firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+		if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+			reader.Comma = ';'
+		}
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFileImporTidakValid, err)
+		}
+		return rows, nil
+	default:
+		return nil, ErrFormatFileTidakDikenal
+	}
+}
+
+// importRow adalah satu baris data yang dibaca berdasarkan nama kolom header
+type importRow struct {
+	cells   []string
+	columns map[string]int
+}
+
+func (r importRow) get(column string) string {
+	idx, ok := r.columns[column]
+	if !ok || idx >= len(r.cells) {
+		return ""
+	}
+	return strings.TrimSpace(r.cells[idx])
+}
+
+func (r importRow) empty() bool {
+	for _, cell := range r.cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// rowValidator mengumpulkan seluruh kesalahan satu baris agar penjual bisa memperbaikinya sekaligus
+type rowValidator struct {
+	row    importRow
+	errors []string
+}
+
+func (v *rowValidator) fail(format string, args ...interface{}) {
+	v.errors = append(v.errors, fmt.Sprintf(format, args...))
+}
+
+// intCell membaca kolom angka opsional. Nilai dari XLSX seperti "15000.0" diterima selama bulat.
+func (v *rowValidator) intCell(column string, min int) *int {
+	raw := v.row.get(column)
+	if raw == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		f, ferr := strconv.ParseFloat(raw, 64)
+		if ferr != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt32 {
+			v.fail("%s harus berupa bilangan bulat", column)
+			return nil
+		}
+		n = int(f)
+	}
+	if n < min {
+		v.fail("%s minimal %d", column, min)
+		return nil
+	}
+	return &n
+}
+
+// productImporter menyimpan keadaan satu tahap impor katalog toko. Pada tahap validasi
+// (validateOnly) baris hanya dibaca tanpa row lock dan tanpa perubahan data.
+type productImporter struct {
+	tx           *gorm.DB
+	validateOnly bool
+	tokoID       uint
+	categories   map[uint]bool
+	slugRows     map[string]int
+	skuRows      map[string]int
+	change       StockChange
+}
+
+// newProductImporter menyiapkan importer untuk satu tahap impor
+func newProductImporter(tokoID uint, categories map[uint]bool, change StockChange, validateOnly bool) *productImporter {
+	return &productImporter{
+		tx:           config.DB,
+		validateOnly: validateOnly,
+		tokoID:       tokoID,
+		categories:   categories,
+		slugRows:     map[string]int{},
+		skuRows:      map[string]int{},
+		change:       change,
+	}
+}
+
+// lock mengunci baris yang dibaca, kecuali pada tahap validasi
+func (im *productImporter) lock(tx *gorm.DB) *gorm.DB {
+	if im.validateOnly {
+		return tx
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"})
+}
+
+// tally menghitung ulang ringkasan hasil impor dari hasil setiap baris
+func (r *ImportResult) tally() {
+	r.Dibuat, r.Diperbarui, r.Gagal, r.JumlahDiterapkan = 0, 0, 0, 0
+	for _, res := range r.Baris {
+		switch {
+		case len(res.Errors) > 0:
+			r.Gagal++
+		case res.Aksi == ImportAksiCreate:
+			r.Dibuat++
+		default:
+			r.Diperbarui++
+		}
+		if res.Diterapkan {
+			r.JumlahDiterapkan++
+		}
+	}
+	r.Diterapkan = r.JumlahDiterapkan == r.JumlahBaris
+}
+
+// ImportProducts mengimpor katalog produk toko user dari file CSV atau XLSX dalam dua tahap.
+// Tahap validasi membaca setiap baris tanpa row lock sehingga laporan memuat semua kesalahan;
+// dry run berhenti di tahap ini. Jika semua baris valid, baris diterapkan per batch di DB
+// transaction pendek tersendiri dan divalidasi ulang di bawah row lock.
+func ImportProducts(userID uint, filename string, data []byte, dryRun bool) (*ImportResult, error) {
+	format, err := SheetFormatFromFilename(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if actor.TokoID == 0 {
+		return nil, ErrAksesProdukDitolak
+	}
+
+	rows, err := readSheet(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, ErrFileImporKosong
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[name]; !ok && name != "" {
+			columns[name] = i
+		}
+	}
+	_, hasSlug := columns["slug"]
+	_, hasSKU := columns["sku"]
+	_, hasNama := columns["nama_produk"]
+	if !hasSlug && !hasSKU && !hasNama {
+		return nil, ErrKolomImporTidakLengkap
+	}
+
+	var dataRows []int
+	for i := 1; i < len(rows); i++ {
+		if !(importRow{cells: rows[i]}).empty() {
+			dataRows = append(dataRows, i)
+		}
+	}
+	if len(dataRows) == 0 {
+		return nil, ErrFileImporKosong
+	}
+	if len(dataRows) > importMaxRows() {
+		return nil, ErrBarisImporTerlaluBanyak
+	}
+
+	referensi := filepath.Base(filename)
+	if len(referensi) > importReferensiMaxLength {
+		referensi = referensi[:importReferensiMaxLength]
+	}
+	change := SellerStockChange(userID, "Impor katalog produk")
+	change.Tipe = models.MovementImport
+	change.Referensi = referensi
+
+	var categoryIDs []uint
+	if err := config.DB.Model(&models.Category{}).Pluck("id", &categoryIDs).Error; err != nil {
+		return nil, err
+	}
+	categories := map[uint]bool{}
+	for _, id := range categoryIDs {
+		categories[id] = true
+	}
+
+	result := &ImportResult{DryRun: dryRun, JumlahBaris: len(dataRows), Baris: []ImportRowResult{}}
+
+	validator := newProductImporter(actor.TokoID, categories, change, true)
+	for _, i := range dataRows {
+		result.Baris = append(result.Baris, validator.importRow(i+1, importRow{cells: rows[i], columns: columns}))
+	}
+	result.tally()
+	if dryRun || result.Gagal > 0 {
+		return result, nil
+	}
+
+	importer := newProductImporter(actor.TokoID, categories, change, false)
+	batchSize := importBatchSize()
+	for start := 0; start < len(dataRows); start += batchSize {
+		end := start + batchSize
+		if end > len(dataRows) {
+			end = len(dataRows)
+		}
+
+		batch := make([]ImportRowResult, 0, end-start)
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			importer.tx = tx
+			gagal := false
+			for _, i := range dataRows[start:end] {
+				res := importer.importRow(i+1, importRow{cells: rows[i], columns: columns})
+				gagal = gagal || len(res.Errors) > 0
+				batch = append(batch, res)
+			}
+			if gagal {
+				return errImporDibatalkan
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errImporDibatalkan) {
+			for k := range batch {
+				batch[k].Errors = append(batch[k].Errors, err.Error())
+			}
+		}
+		for k := range batch {
+			batch[k].Diterapkan = err == nil
+			result.Baris[start+k] = batch[k]
+		}
+		// Batch berikutnya tidak diterapkan agar penjual bisa memperbaiki lalu mengulang impor
+		if err != nil {
+			break
+		}
+	}
+	result.tally()
+	return result, nil
+}
+
+// importRow memproses satu baris, di savepoint tersendiri saat diterapkan. Kesalahan validasi
+// maupun database dicatat pada hasil baris dan perubahan baris tersebut dibatalkan.
+func (im *productImporter) importRow(baris int, row importRow) ImportRowResult {
+	res := ImportRowResult{Baris: baris, Slug: row.get("slug"), SKU: row.get("sku")}
+	v := &rowValidator{row: row}
+
+	process := func(tx *gorm.DB) error {
+		var err error
+		if res.SKU != "" {
+			err = im.importVariantRow(tx, v, &res)
+		} else {
+			err = im.importProductRow(tx, v, &res)
+		}
+		if err == nil && len(v.errors) > 0 {
+			err = errBarisImporTidakValid
+		}
+		return err
+	}
+
+	var err error
+	if im.validateOnly {
+		err = process(im.tx)
+	} else {
+		err = im.tx.Transaction(process)
+	}
+	if err != nil && !errors.Is(err, errBarisImporTidakValid) {
+		v.fail("%s", err.Error())
+	}
+	res.Errors = v.errors
+	return res
+}
+
+// importProductRow membuat produk baru atau memperbarui produk toko dengan slug yang sama.
+// Kolom kosong pada produk yang sudah ada tidak mengubah nilainya.
+func (im *productImporter) importProductRow(tx *gorm.DB, v *rowValidator, res *ImportRowResult) error {
+	nama := v.row.get("nama_produk")
+	slug := res.Slug
+	if slug == "" {
+		slug = nama
+	}
+	slug = utils.GenerateSlug(slug)
+	res.Slug = slug
+	if slug == "" {
+		v.fail("slug atau nama_produk wajib diisi")
+		return nil
+	}
+	if prev, ok := im.slugRows[slug]; ok {
+		v.fail("slug %s sudah dipakai di baris %d", slug, prev)
+		return nil
+	}
+	im.slugRows[slug] = res.Baris
+
+	idCategory := v.intCell("id_category", 1)
+	// Harga reseller 0 berarti produk tidak punya harga reseller, sama seperti pada UnitPrice
+	hargaReseller := v.intCell("harga_reseller", 0)
+	hargaKonsumen := v.intCell("harga_konsumen", 1)
+	stok := v.intCell("stok", 0)
+	stokMinimum := v.intCell("stok_minimum", 0)
+	berat := v.intCell("berat", 1)
+	if idCategory != nil && !im.categories[uint(*idCategory)] {
+		v.fail("kategori %d tidak ditemukan", *idCategory)
+	}
+
+	var existing []models.Produk
+	// Produk yang diarsipkan ikut dicari agar impor tidak membuat produk kembar dengan slug yang sama
+	if err := im.lock(tx.Unscoped()).Where("slug = ?", slug).
+		Order("id ASC").Find(&existing).Error; err != nil {
+		return err
+	}
+	var produk *models.Produk
+	for i := range existing {
+		if existing[i].IDToko != im.tokoID {
+			v.fail("slug %s sudah dipakai toko lain", slug)
+			return nil
+		}
+		if produk != nil {
+			v.fail("slug %s dipakai lebih dari satu produk toko", slug)
+			return nil
+		}
+		produk = &existing[i]
+	}
+	if produk != nil && produk.DeletedAt.Valid {
+		v.fail("produk %s diarsipkan, pulihkan produk terlebih dahulu", slug)
+		return nil
+	}
+
+	if produk == nil {
+		res.Aksi = ImportAksiCreate
+		if nama == "" {
+			v.fail("nama_produk wajib diisi untuk produk baru")
+		}
+		if idCategory == nil {
+			v.fail("id_category wajib diisi untuk produk baru")
+		}
+		if hargaReseller == nil {
+			v.fail("harga_reseller wajib diisi untuk produk baru")
+		}
+		if hargaKonsumen == nil {
+			v.fail("harga_konsumen wajib diisi untuk produk baru")
+		}
+		if len(v.errors) > 0 || im.validateOnly {
+			return nil
+		}
+
+		produk = &models.Produk{
+			NamaProduk:    nama,
+			Slug:          slug,
+			HargaReseller: *hargaReseller,
+			HargaKonsumen: *hargaKonsumen,
+			Berat:         BeratDefaultGram,
+			Deskripsi:     v.row.get("deskripsi"),
+			IDToko:        im.tokoID,
+			IDCategory:    uint(*idCategory),
+		}
+		if stok != nil {
+			produk.Stok = *stok
+		}
+		if stokMinimum != nil {
+			produk.StokMinimum = *stokMinimum
+		}
+		if berat != nil {
+			produk.Berat = *berat
+		}
+		if err := tx.Create(produk).Error; err != nil {
+			return err
+		}
+		res.IDProduk = produk.ID
+		if _, err := SnapshotProduct(tx, produk, nil); err != nil {
+			return err
+		}
+		return RecordStockMovement(tx, produk.ID, nil, produk.Stok, im.change)
+	}
+
+	res.Aksi = ImportAksiUpdate
+	res.IDProduk = produk.ID
+	if stok != nil && hasVariants(tx, produk.ID) {
+		v.fail("stok produk bervarian diatur melalui baris sku varian")
+	}
+	if len(v.errors) > 0 || im.validateOnly {
+		return nil
+	}
+
+	if nama != "" {
+		produk.NamaProduk = nama
+	}
+	if deskripsi := v.row.get("deskripsi"); deskripsi != "" {
+		produk.Deskripsi = deskripsi
+	}
+	if idCategory != nil {
+		produk.IDCategory = uint(*idCategory)
+	}
+	if hargaReseller != nil {
+		produk.HargaReseller = *hargaReseller
+	}
+	if hargaKonsumen != nil {
+		produk.HargaKonsumen = *hargaKonsumen
+	}
+	if stokMinimum != nil {
+		produk.StokMinimum = *stokMinimum
+	}
+	if berat != nil {
+		produk.Berat = *berat
+	}
+	if err := tx.Omit("stok").Save(produk).Error; err != nil {
+		return err
+	}
+	if _, err := SnapshotProduct(tx, produk, nil); err != nil {
+		return err
+	}
+	if stok != nil {
+		return SetStock(tx, produk.ID, *stok, im.change)
+	}
+	return nil
+}
+
+// importVariantRow memperbarui harga dan stok varian toko berdasarkan SKU. Harga 0 mengembalikan
+// harga varian ke harga produk. Kolom produk pada baris varian diabaikan. Impor tidak membuat varian
+// baru karena varian membutuhkan nilai opsi; SKU yang belum ada ditolak.
+func (im *productImporter) importVariantRow(tx *gorm.DB, v *rowValidator, res *ImportRowResult) error {
+	res.Aksi = ImportAksiUpdateVarian
+	if prev, ok := im.skuRows[res.SKU]; ok {
+		v.fail("sku %s sudah dipakai di baris %d", res.SKU, prev)
+		return nil
+	}
+	im.skuRows[res.SKU] = res.Baris
+
+	hargaReseller := v.intCell("harga_reseller", 0)
+	hargaKonsumen := v.intCell("harga_konsumen", 0)
+	stok := v.intCell("stok", 0)
+
+	var found models.ProdukVarian
+	if err := tx.Where("sku = ?", res.SKU).First(&found).Error; err != nil {
+		v.fail(importPesanSKUTidakDikenal)
+		return nil
+	}
+
+	// Urutan lock sama dengan checkout: produk dulu, lalu varian
+	var produk models.Produk
+	if err := im.lock(tx).First(&produk, found.IDProduk).Error; err != nil {
+		v.fail(importPesanSKUTidakDikenal)
+		return nil
+	}
+	res.IDProduk = produk.ID
+	if produk.IDToko != im.tokoID {
+		v.fail("sku %s milik toko lain", res.SKU)
+		return nil
+	}
+	if res.Slug != "" && utils.GenerateSlug(res.Slug) != produk.Slug {
+		v.fail("slug %s bukan slug produk dari sku %s", res.Slug, res.SKU)
+	}
+	if len(v.errors) > 0 || im.validateOnly {
+		return nil
+	}
+
+	varian, err := resolveVariant(tx, produk.ID, found.ID, true)
+	if err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{}
+	for column, harga := range map[string]*int{
+		"harga_reseller": hargaReseller,
+		"harga_konsumen": hargaKonsumen,
+	} {
+		switch {
+		case harga == nil:
+		case *harga == 0:
+			updates[column] = nil
+		default:
+			updates[column] = *harga
+		}
+	}
+	if len(updates) > 0 {
+		if err := tx.Model(varian).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	if stok != nil {
+		return SetVariantStock(tx, produk.ID, varian.ID, *stok, im.change)
+	}
+	return nil
+}
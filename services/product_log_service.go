@@ -0,0 +1,87 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// latestLogProduk mengambil versi snapshot terbaru sebuah produk, atau varian produk jika varianID
+// diisi. Dibaca dengan locking read agar snapshot yang di-commit transaksi lain setelah transaksi
+// ini dimulai tetap terlihat.
+func latestLogProduk(tx *gorm.DB, produkID uint, varianID *uint) (*models.LogProduk, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_produk = ?", produkID)
+	if varianID != nil {
+		query = query.Where("id_varian = ?", *varianID)
+	} else {
+		query = query.Where("id_varian IS NULL")
+	}
+
+	var logProduk models.LogProduk
+	err := query.Order("versi DESC, id DESC").First(&logProduk).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &logProduk, nil
+}
+
+// logProdukMatches mengecek apakah snapshot masih sama dengan data produk saat ini
+func logProdukMatches(logProduk, current *models.LogProduk) bool {
+	return logProduk.NamaProduk == current.NamaProduk &&
+		logProduk.Slug == current.Slug &&
+		logProduk.SKU == current.SKU &&
+		logProduk.NamaVarian == current.NamaVarian &&
+		logProduk.HargaReseller == current.HargaReseller &&
+		logProduk.HargaKonsumen == current.HargaKonsumen &&
+		logProduk.Deskripsi == current.Deskripsi &&
+		logProduk.IDToko == current.IDToko &&
+		logProduk.IDCategory == current.IDCategory
+}
+
+// SnapshotProduct mengembalikan snapshot LogProduk yang sesuai dengan data produk (dan varian,
+// jika diisi) saat ini. Versi baru dibuat jika harga atau data deskriptif berubah sejak snapshot
+// terakhir. Nomor versi baru melanjutkan versi tertinggi produk, termasuk snapshot varian lain.
+// Pemanggil harus memegang row lock produk agar nomor versi tidak bentrok.
+func SnapshotProduct(tx *gorm.DB, produk *models.Produk, varian *models.ProdukVarian) (models.LogProduk, error) {
+	effective := applyVariant(produk, varian)
+	current := models.LogProduk{
+		IDProduk:      produk.ID,
+		NamaProduk:    produk.NamaProduk,
+		Slug:          produk.Slug,
+		HargaReseller: effective.HargaReseller,
+		HargaKonsumen: effective.HargaKonsumen,
+		Deskripsi:     produk.Deskripsi,
+		IDToko:        produk.IDToko,
+		IDCategory:    produk.IDCategory,
+	}
+	if varian != nil {
+		current.IDVarian = &varian.ID
+		current.SKU = varian.SKU
+		current.NamaVarian = varian.Nama
+	}
+
+	latest, err := latestLogProduk(tx, produk.ID, current.IDVarian)
+	if err != nil {
+		return models.LogProduk{}, err
+	}
+	if latest != nil && logProdukMatches(latest, &current) {
+		return *latest, nil
+	}
+
+	// Locking read: snapshot yang di-commit sebelum row lock produk didapat harus ikut terhitung
+	var maxVersi int
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.LogProduk{}).Where("id_produk = ?", produk.ID).
+		Select("COALESCE(MAX(versi), 0)").Scan(&maxVersi).Error; err != nil {
+		return models.LogProduk{}, err
+	}
+	current.Versi = maxVersi + 1
+	if err := tx.Create(&current).Error; err != nil {
+		return current, errors.New("gagal menyimpan ke log produk")
+	}
+	return current, nil
+}
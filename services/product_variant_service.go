@@ -0,0 +1,475 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVarianTidakDitemukan = errors.New("varian produk tidak ditemukan")
+	ErrVarianWajibDipilih   = errors.New("varian produk wajib dipilih")
+	ErrDataVarianTidakValid = errors.New("data varian produk tidak valid")
+	ErrSKUSudahDipakai      = errors.New("SKU sudah dipakai produk lain")
+	ErrAksesProdukDitolak   = errors.New("anda tidak memiliki izin untuk mengubah produk ini")
+)
+
+// VariantOptionInput adalah satu jenis pilihan beserta nilai-nilainya
+type VariantOptionInput struct {
+	Nama  string   `json:"nama"`
+	Nilai []string `json:"nilai"`
+}
+
+// VariantInput adalah satu kombinasi varian. Nilai diisi satu untuk setiap opsi sesuai urutan
+// opsi; SKU dibuat otomatis jika kosong dan harga yang kosong mengikuti harga produk.
+type VariantInput struct {
+	SKU           string   `json:"sku"`
+	Nilai         []string `json:"nilai"`
+	HargaReseller *int     `json:"harga_reseller"`
+	HargaKonsumen *int     `json:"harga_konsumen"`
+	Stok          int      `json:"stok"`
+	URLFoto       string   `json:"url_foto"`
+}
+
+// ProductVariantsInput adalah susunan lengkap opsi dan varian sebuah produk
+type ProductVariantsInput struct {
+	Opsi   []VariantOptionInput `json:"opsi"`
+	Varian []VariantInput       `json:"varian"`
+}
+
+// VariantUpdate berisi perubahan satu varian. Field nil tidak diubah; harga 0 mengembalikan
+// harga varian ke harga produk.
+type VariantUpdate struct {
+	HargaReseller *int
+	HargaKonsumen *int
+	Stok          *int
+	URLFoto       string
+}
+
+// ProductVariants adalah opsi dan varian sebuah produk
+type ProductVariants struct {
+	Opsi   []models.ProdukOpsi   `json:"opsi"`
+	Varian []models.ProdukVarian `json:"varian"`
+}
+
+// applyVariant mengembalikan salinan produk dengan harga dan stok varian, sehingga perhitungan
+// harga dan stok yang sama bisa dipakai untuk produk dengan maupun tanpa varian
+func applyVariant(produk *models.Produk, varian *models.ProdukVarian) models.Produk {
+	effective := *produk
+	if varian == nil {
+		return effective
+	}
+	if varian.HargaReseller != nil {
+		effective.HargaReseller = *varian.HargaReseller
+	}
+	if varian.HargaKonsumen != nil {
+		effective.HargaKonsumen = *varian.HargaKonsumen
+	}
+	effective.Stok = varian.Stok
+	return effective
+}
+
+// hasVariants mengecek apakah produk dijual per varian
+func hasVariants(db *gorm.DB, produkID uint) bool {
+	var count int64
+	db.Model(&models.ProdukVarian{}).Where("id_produk = ?", produkID).Count(&count)
+	return count > 0
+}
+
+// resolveVariant mengambil varian yang dipilih pembeli. Produk bervarian wajib memilih varian,
+// produk tanpa varian tidak boleh memilih varian. Jika lock bernilai true, varian dikunci.
+func resolveVariant(db *gorm.DB, produkID, varianID uint, lock bool) (*models.ProdukVarian, error) {
+	if varianID == 0 {
+		if hasVariants(db, produkID) {
+			return nil, ErrVarianWajibDipilih
+		}
+		return nil, nil
+	}
+
+	query := db.Where("id = ? AND id_produk = ?", varianID, produkID)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+
+	var varian models.ProdukVarian
+	if err := query.First(&varian).Error; err != nil {
+		return nil, ErrVarianTidakDitemukan
+	}
+	return &varian, nil
+}
+
+// normalizeVariantsInput memvalidasi susunan opsi dan varian lalu melengkapi SKU yang kosong
+func normalizeVariantsInput(produk *models.Produk, input *ProductVariantsInput) error {
+	// Opsi dan varian diisi bersamaan, atau dikosongkan bersamaan untuk menghapus varian
+	if (len(input.Opsi) == 0) != (len(input.Varian) == 0) {
+		return ErrDataVarianTidakValid
+	}
+
+	opsiNames := map[string]bool{}
+	for i := range input.Opsi {
+		opsi := &input.Opsi[i]
+		opsi.Nama = strings.TrimSpace(opsi.Nama)
+		key := strings.ToLower(opsi.Nama)
+		if opsi.Nama == "" || opsiNames[key] || len(opsi.Nilai) == 0 {
+			return ErrDataVarianTidakValid
+		}
+		opsiNames[key] = true
+
+		nilaiSet := map[string]bool{}
+		for j := range opsi.Nilai {
+			opsi.Nilai[j] = strings.TrimSpace(opsi.Nilai[j])
+			key := strings.ToLower(opsi.Nilai[j])
+			if opsi.Nilai[j] == "" || nilaiSet[key] {
+				return ErrDataVarianTidakValid
+			}
+			nilaiSet[key] = true
+		}
+	}
+
+	skus := map[string]bool{}
+	combinations := map[string]bool{}
+	for i := range input.Varian {
+		varian := &input.Varian[i]
+		if len(varian.Nilai) != len(input.Opsi) || varian.Stok < 0 {
+			return ErrDataVarianTidakValid
+		}
+		if (varian.HargaReseller != nil && *varian.HargaReseller <= 0) ||
+			(varian.HargaKonsumen != nil && *varian.HargaKonsumen <= 0) {
+			return ErrDataVarianTidakValid
+		}
+
+		for j, nilai := range varian.Nilai {
+			varian.Nilai[j] = strings.TrimSpace(nilai)
+			if findOptionValue(input.Opsi[j].Nilai, varian.Nilai[j]) < 0 {
+				return ErrDataVarianTidakValid
+			}
+		}
+		combination := strings.ToLower(strings.Join(varian.Nilai, "|"))
+		if combinations[combination] {
+			return ErrDataVarianTidakValid
+		}
+		combinations[combination] = true
+
+		varian.SKU = strings.ToUpper(strings.TrimSpace(varian.SKU))
+		if varian.SKU == "" {
+			varian.SKU = generateVariantSKU(produk, varian.Nilai)
+		}
+		if skus[varian.SKU] {
+			return ErrDataVarianTidakValid
+		}
+		skus[varian.SKU] = true
+	}
+	return nil
+}
+
+// findOptionValue mencari posisi nilai pada daftar nilai opsi tanpa membedakan huruf besar kecil
+func findOptionValue(values []string, nilai string) int {
+	for i, v := range values {
+		if strings.EqualFold(v, nilai) {
+			return i
+		}
+	}
+	return -1
+}
+
+// generateVariantSKU membuat SKU dari ID produk dan nilai opsi, misalnya P12-MERAH-XL
+func generateVariantSKU(produk *models.Produk, nilai []string) string {
+	parts := []string{fmt.Sprintf("P%d", produk.ID)}
+	for _, n := range nilai {
+		parts = append(parts, strings.Join(strings.Fields(strings.ToUpper(n)), "-"))
+	}
+	return strings.Join(parts, "-")
+}
+
+// deleteProductOptions menghapus seluruh opsi dan nilai opsi produk
+func deleteProductOptions(tx *gorm.DB, produkID uint) error {
+	var opsiIDs []uint
+	if err := tx.Model(&models.ProdukOpsi{}).Where("id_produk = ?", produkID).Pluck("id", &opsiIDs).Error; err != nil {
+		return err
+	}
+	if len(opsiIDs) == 0 {
+		return nil
+	}
+	if err := tx.Where("id_opsi IN ?", opsiIDs).Delete(&models.ProdukOpsiNilai{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("id IN ?", opsiIDs).Delete(&models.ProdukOpsi{}).Error
+}
+
+// SetProductVariants mengganti susunan opsi dan varian produk di dalam DB transaction yang sedang
+// berjalan. Varian dengan SKU yang sama diperbarui di tempat sehingga keranjang dan riwayat
+// pesanan yang merujuknya tetap valid; varian yang tidak ada di input dihapus. Perubahan stok
+// setiap varian dicatat di ledger dengan keterangan change.
+func SetProductVariants(tx *gorm.DB, produk *models.Produk, input ProductVariantsInput, change StockChange) error {
+	if err := normalizeVariantsInput(produk, &input); err != nil {
+		return err
+	}
+
+	skus := make([]string, 0, len(input.Varian))
+	for _, v := range input.Varian {
+		skus = append(skus, v.SKU)
+	}
+	if len(skus) > 0 {
+		var count int64
+		tx.Model(&models.ProdukVarian{}).Where("sku IN ? AND id_produk <> ?", skus, produk.ID).Count(&count)
+		if count > 0 {
+			return ErrSKUSudahDipakai
+		}
+	}
+
+	var existing []models.ProdukVarian
+	if err := tx.Where("id_produk = ?", produk.ID).Find(&existing).Error; err != nil {
+		return err
+	}
+	// Produk yang mulai dijual per varian menutup saldo ledger tingkat produknya
+	if len(existing) == 0 && len(input.Varian) > 0 {
+		if err := rebaseLedgerToVariants(tx, produk.ID, change); err != nil {
+			return err
+		}
+	}
+	bySKU := map[string]*models.ProdukVarian{}
+	for i := range existing {
+		if err := tx.Model(&existing[i]).Association("Nilai").Clear(); err != nil {
+			return err
+		}
+		bySKU[existing[i].SKU] = &existing[i]
+	}
+
+	if err := deleteProductOptions(tx, produk.ID); err != nil {
+		return err
+	}
+
+	opsiList := make([]models.ProdukOpsi, 0, len(input.Opsi))
+	for i, o := range input.Opsi {
+		opsi := models.ProdukOpsi{IDProduk: produk.ID, Nama: o.Nama, Urutan: i}
+		for j, nilai := range o.Nilai {
+			opsi.Nilai = append(opsi.Nilai, models.ProdukOpsiNilai{Nilai: nilai, Urutan: j})
+		}
+		if err := tx.Create(&opsi).Error; err != nil {
+			return err
+		}
+		opsiList = append(opsiList, opsi)
+	}
+
+	for _, v := range input.Varian {
+		nilaiList := make([]models.ProdukOpsiNilai, 0, len(v.Nilai))
+		names := make([]string, 0, len(v.Nilai))
+		for j, nilai := range v.Nilai {
+			optionValue := opsiList[j].Nilai[findOptionValue(input.Opsi[j].Nilai, nilai)]
+			nilaiList = append(nilaiList, optionValue)
+			names = append(names, optionValue.Nilai)
+		}
+
+		varian, ok := bySKU[v.SKU]
+		stokLama := 0
+		if ok {
+			delete(bySKU, v.SKU)
+			stokLama = varian.Stok
+			if err := tx.Model(varian).Updates(map[string]interface{}{
+				"nama":           strings.Join(names, " / "),
+				"harga_reseller": v.HargaReseller,
+				"harga_konsumen": v.HargaKonsumen,
+				"stok":           v.Stok,
+				"url_foto":       v.URLFoto,
+			}).Error; err != nil {
+				return err
+			}
+		} else {
+			varian = &models.ProdukVarian{
+				IDProduk:      produk.ID,
+				SKU:           v.SKU,
+				Nama:          strings.Join(names, " / "),
+				HargaReseller: v.HargaReseller,
+				HargaKonsumen: v.HargaKonsumen,
+				Stok:          v.Stok,
+				URLFoto:       v.URLFoto,
+			}
+			if err := tx.Omit("Nilai").Create(varian).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(varian).Association("Nilai").Replace(nilaiList); err != nil {
+			return err
+		}
+		if err := recordMovement(tx, produk.ID, &varian.ID, v.Stok-stokLama, change); err != nil {
+			return err
+		}
+	}
+
+	for _, varian := range bySKU {
+		if err := deleteVariantRow(tx, varian, change); err != nil {
+			return err
+		}
+	}
+
+	// Produk yang tidak lagi bervarian mempertahankan stok terakhirnya, dicatat di ledger produk
+	if len(input.Varian) == 0 {
+		if len(existing) == 0 {
+			return nil
+		}
+		return rebaseLedgerFromVariants(tx, produk.ID, change)
+	}
+	return syncVariantStock(tx, produk.ID)
+}
+
+// deleteVariantRow menghapus varian dan mencatat stok yang ikut hilang di ledger
+func deleteVariantRow(tx *gorm.DB, varian *models.ProdukVarian, change StockChange) error {
+	if err := tx.Model(varian).Association("Nilai").Clear(); err != nil {
+		return err
+	}
+	if err := tx.Delete(varian).Error; err != nil {
+		return err
+	}
+	change.Alasan = "Varian " + varian.SKU + " dihapus"
+	return recordMovement(tx, varian.IDProduk, &varian.ID, -varian.Stok, change)
+}
+
+// DeleteProductVariants menghapus seluruh opsi dan varian produk, dipakai saat produk dihapus
+func DeleteProductVariants(tx *gorm.DB, produkID uint) error {
+	var varians []models.ProdukVarian
+	if err := tx.Where("id_produk = ?", produkID).Find(&varians).Error; err != nil {
+		return err
+	}
+	for i := range varians {
+		if err := tx.Model(&varians[i]).Association("Nilai").Clear(); err != nil {
+			return err
+		}
+	}
+	if err := tx.Where("id_produk = ?", produkID).Delete(&models.ProdukVarian{}).Error; err != nil {
+		return err
+	}
+	return deleteProductOptions(tx, produkID)
+}
+
+// lockManagedProduct mengunci produk dan memastikan user boleh mengubahnya
+func lockManagedProduct(tx *gorm.DB, userID, produkID uint) (*models.Produk, error) {
+	var produk models.Produk
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&produk, produkID).Error; err != nil {
+		return nil, ErrProdukTidakDitemukan
+	}
+
+	actor, err := resolveActor(tx, userID)
+	if err != nil || !actor.CanManageProduct(&produk) {
+		return nil, ErrAksesProdukDitolak
+	}
+	return &produk, nil
+}
+
+// GetProductVariants mengambil opsi dan varian produk beserta nilai opsinya
+func GetProductVariants(produkID uint) (*ProductVariants, error) {
+	var produk models.Produk
+	if err := config.DB.First(&produk, produkID).Error; err != nil {
+		return nil, ErrProdukTidakDitemukan
+	}
+
+	result := &ProductVariants{Opsi: []models.ProdukOpsi{}, Varian: []models.ProdukVarian{}}
+	if err := config.DB.Preload("Nilai", func(db *gorm.DB) *gorm.DB {
+		return db.Order("urutan ASC")
+	}).Where("id_produk = ?", produkID).Order("urutan ASC").Find(&result.Opsi).Error; err != nil {
+		return nil, err
+	}
+	if err := config.DB.Preload("Nilai").Where("id_produk = ?", produkID).
+		Order("id ASC").Find(&result.Varian).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReplaceProductVariants mengganti susunan opsi dan varian produk milik toko user
+func ReplaceProductVariants(userID, produkID uint, input ProductVariantsInput) (*ProductVariants, error) {
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		produk, err := lockManagedProduct(tx, userID, produkID)
+		if err != nil {
+			return err
+		}
+		return SetProductVariants(tx, produk, input, SellerStockChange(userID, "Susunan varian diubah"))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return GetProductVariants(produkID)
+}
+
+// UpdateVariant mengubah harga, stok atau foto satu varian milik toko user
+func UpdateVariant(userID, produkID, varianID uint, input VariantUpdate) (*models.ProdukVarian, error) {
+	var varian models.ProdukVarian
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockManagedProduct(tx, userID, produkID); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND id_produk = ?", varianID, produkID).First(&varian).Error; err != nil {
+			return ErrVarianTidakDitemukan
+		}
+
+		updates := map[string]interface{}{}
+		for column, harga := range map[string]*int{
+			"harga_reseller": input.HargaReseller,
+			"harga_konsumen": input.HargaKonsumen,
+		} {
+			switch {
+			case harga == nil:
+			case *harga < 0:
+				return ErrDataVarianTidakValid
+			case *harga == 0:
+				updates[column] = nil
+			default:
+				updates[column] = *harga
+			}
+		}
+		if input.URLFoto != "" {
+			updates["url_foto"] = input.URLFoto
+		}
+		if len(updates) > 0 {
+			if err := tx.Model(&varian).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+
+		if input.Stok != nil {
+			return SetVariantStock(tx, produkID, varianID, *input.Stok,
+				SellerStockChange(userID, "Stok varian diubah"))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := config.DB.Preload("Nilai").First(&varian, varianID).Error; err != nil {
+		return nil, err
+	}
+	return &varian, nil
+}
+
+// DeleteVariant menghapus satu varian milik toko user. Jika varian terakhir dihapus, opsi
+// produk ikut dihapus dan produk kembali dijual tanpa varian dengan stok terakhirnya.
+func DeleteVariant(userID, produkID, varianID uint) error {
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := lockManagedProduct(tx, userID, produkID); err != nil {
+			return err
+		}
+
+		var varian models.ProdukVarian
+		if err := tx.Where("id = ? AND id_produk = ?", varianID, produkID).First(&varian).Error; err != nil {
+			return ErrVarianTidakDitemukan
+		}
+		change := SellerStockChange(userID, "")
+		if err := deleteVariantRow(tx, &varian, change); err != nil {
+			return err
+		}
+
+		if !hasVariants(tx, produkID) {
+			if err := deleteProductOptions(tx, produkID); err != nil {
+				return err
+			}
+			return rebaseLedgerFromVariants(tx, produkID, change)
+		}
+		return syncVariantStock(tx, produkID)
+	})
+}
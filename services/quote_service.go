@@ -0,0 +1,215 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// QuoteLine adalah rincian harga satu baris checkout. Error diisi jika baris tidak dapat dibeli.
+type QuoteLine struct {
+	ProductID   uint   `json:"product_id"`
+	IDVarian    uint   `json:"id_varian,omitempty"`
+	NamaProduk  string `json:"nama_produk,omitempty"`
+	NamaVarian  string `json:"nama_varian,omitempty"`
+	SKU         string `json:"sku,omitempty"`
+	Kuantitas   int    `json:"kuantitas"`
+	Stok        int    `json:"stok"`
+	TierHarga   string `json:"tier_harga,omitempty"`
+	HargaSatuan int    `json:"harga_satuan"`
+	HargaTotal  int    `json:"harga_total"`
+	HargaJual   int    `json:"harga_jual,omitempty"`
+	Margin      int    `json:"margin,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// QuoteStore adalah rincian checkout untuk satu toko, setara dengan satu sub-transaksi
+type QuoteStore struct {
+	IDToko        uint        `json:"id_toko"`
+	Items         []QuoteLine `json:"items"`
+	Subtotal      int         `json:"subtotal"`
+	Berat         int         `json:"berat"`
+	OngkosKirim   int         `json:"ongkos_kirim"`
+	EstimasiKirim string      `json:"estimasi_kirim,omitempty"`
+	Diskon        int         `json:"diskon"`
+	Total         int         `json:"total"`
+}
+
+// Quote adalah hasil simulasi checkout tanpa membuat transaksi maupun mengubah stok
+type Quote struct {
+	Toko        []QuoteStore `json:"toko"`
+	Subtotal    int          `json:"subtotal"`
+	OngkosKirim int          `json:"ongkos_kirim"`
+	Diskon      int          `json:"diskon"`
+	HargaTotal  int          `json:"harga_total"`
+	Valid       bool         `json:"valid"`  // true jika request dapat di-checkout apa adanya
+	Errors      []string     `json:"errors"` // error tingkat request, misalnya alamat atau metode bayar
+}
+
+// QuoteTransaction menjalankan perhitungan harga dan validasi yang sama dengan CreateTransaction
+// secara read-only. Semua masalah dikumpulkan per baris alih-alih berhenti di error pertama.
+func QuoteTransaction(userID uint, req CheckoutRequest) (*Quote, error) {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+
+	quote := &Quote{Toko: []QuoteStore{}, Errors: []string{}}
+
+	if len(req.DetailTransaksi) == 0 {
+		quote.Errors = append(quote.Errors, ErrDetailTransaksiKosong.Error())
+	}
+	if err := validateDropship(&user, &req); err != nil {
+		quote.Errors = append(quote.Errors, err.Error())
+	}
+	if req.MethodBayar != "" {
+		if _, err := GetPaymentProvider(req.MethodBayar); err != nil {
+			quote.Errors = append(quote.Errors, err.Error())
+		}
+	}
+	// Ongkos kirim hanya dapat dihitung jika alamat kirim diisi
+	var alamat *models.Alamat
+	if req.AlamatPengiriman != 0 {
+		var a models.Alamat
+		if err := config.DB.Where("id = ? AND id_user = ?", req.AlamatPengiriman, userID).First(&a).Error; err != nil {
+			quote.Errors = append(quote.Errors, ErrAlamatTidakDitemukan.Error())
+		} else {
+			alamat = &a
+		}
+	}
+
+	tier := PriceTierForUser(&user)
+	stores := map[uint]*QuoteStore{}
+	var tokoIDs []uint
+	// Kuantitas per produk/varian dijumlahkan agar yang muncul di beberapa baris tetap dicek terhadap stok
+	type stockKey struct{ produkID, varianID uint }
+	requested := map[stockKey]int{}
+	valid := len(quote.Errors) == 0
+	var lines []checkoutLine
+	storeLines := map[uint][]checkoutLine{}
+
+	for _, item := range req.DetailTransaksi {
+		line := QuoteLine{ProductID: item.ProductID, IDVarian: item.IDVarian, Kuantitas: item.Kuantitas}
+
+		var produk models.Produk
+		if err := config.DB.First(&produk, item.ProductID).Error; err != nil {
+			line.Error = ErrProdukTidakDitemukan.Error()
+			valid = false
+			store := quoteStore(stores, &tokoIDs, 0)
+			store.Items = append(store.Items, line)
+			continue
+		}
+		line.NamaProduk = produk.NamaProduk
+
+		varian, err := resolveVariant(config.DB, produk.ID, item.IDVarian, false)
+		if err != nil {
+			line.Error = err.Error()
+			valid = false
+			store := quoteStore(stores, &tokoIDs, produk.IDToko)
+			store.Items = append(store.Items, line)
+			continue
+		}
+		stok := applyVariant(&produk, varian).Stok
+		line.Stok = stok
+		if varian != nil {
+			line.NamaVarian = varian.Nama
+			line.SKU = varian.SKU
+		}
+
+		priced, err := priceCheckoutLine(&produk, varian, item, tier, req.IsDropship)
+		if err == nil {
+			key := stockKey{produk.ID, item.IDVarian}
+			requested[key] += item.Kuantitas
+			if requested[key] > stok {
+				err = ErrStokTidakMencukupi
+			}
+		}
+
+		line.TierHarga = priced.TierHarga
+		line.HargaSatuan = priced.HargaSatuan
+		line.HargaTotal = priced.HargaTotal
+		line.HargaJual = priced.HargaJual
+		line.Margin = priced.Margin
+
+		store := quoteStore(stores, &tokoIDs, produk.IDToko)
+		if err != nil {
+			line.Error = err.Error()
+			valid = false
+		} else {
+			store.Subtotal += line.HargaTotal
+			lines = append(lines, priced)
+			storeLines[produk.IDToko] = append(storeLines[produk.IDToko], priced)
+		}
+		store.Items = append(store.Items, line)
+	}
+
+	// Potongan voucher dihitung dari baris yang valid tanpa memakai kuotanya
+	if strings.TrimSpace(req.KodeVoucher) != "" && len(lines) > 0 {
+		diskon, err := EvaluateVoucher(config.DB, userID, req.KodeVoucher, lines)
+		if err != nil {
+			quote.Errors = append(quote.Errors, err.Error())
+			valid = false
+		}
+		for tokoID, store := range stores {
+			store.Diskon = diskon.ForToko(tokoID)
+		}
+	}
+
+	if alamat != nil {
+		if err := quoteShipping(stores, storeLines, alamat); err != nil {
+			quote.Errors = append(quote.Errors, err.Error())
+			valid = false
+		}
+	}
+
+	sort.Slice(tokoIDs, func(i, j int) bool { return tokoIDs[i] < tokoIDs[j] })
+	for _, tokoID := range tokoIDs {
+		store := stores[tokoID]
+		store.Total = store.Subtotal + store.OngkosKirim - store.Diskon
+		quote.Subtotal += store.Subtotal
+		quote.OngkosKirim += store.OngkosKirim
+		quote.Diskon += store.Diskon
+		quote.Toko = append(quote.Toko, *store)
+	}
+	quote.HargaTotal = quote.Subtotal + quote.OngkosKirim - quote.Diskon
+	quote.Valid = valid
+
+	return quote, nil
+}
+
+// quoteStore mengambil atau membuat rincian toko pada quote
+func quoteStore(stores map[uint]*QuoteStore, tokoIDs *[]uint, tokoID uint) *QuoteStore {
+	store, ok := stores[tokoID]
+	if !ok {
+		store = &QuoteStore{IDToko: tokoID, Items: []QuoteLine{}}
+		stores[tokoID] = store
+		*tokoIDs = append(*tokoIDs, tokoID)
+	}
+	return store
+}
+
+// quoteShipping mengisi ongkos kirim setiap toko pada quote berdasarkan baris yang valid
+func quoteShipping(stores map[uint]*QuoteStore, storeLines map[uint][]checkoutLine, alamat *models.Alamat) error {
+	shipping, err := GetShippingRateProvider()
+	if err != nil {
+		return err
+	}
+
+	for tokoID, lines := range storeLines {
+		var toko models.Toko
+		if err := config.DB.First(&toko, tokoID).Error; err != nil {
+			return errors.New("toko tidak ditemukan")
+		}
+		rate, berat, err := shippingFeeForStore(shipping, &toko, alamat, lines)
+		if err != nil {
+			return err
+		}
+		stores[tokoID].OngkosKirim = rate.Ongkir
+		stores[tokoID].Berat = berat
+		stores[tokoID].EstimasiKirim = rate.Estimasi
+	}
+	return nil
+}
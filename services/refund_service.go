@@ -0,0 +1,475 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefundTidakDitemukan          = errors.New("refund tidak ditemukan")
+	ErrDetailTransaksiTidakDitemukan = errors.New("detail transaksi tidak ditemukan")
+	ErrAlasanRefundKosong            = errors.New("alasan refund wajib diisi")
+	ErrKuantitasRefundMelebihi       = errors.New("kuantitas refund melebihi kuantitas yang dapat dikembalikan")
+	ErrTransaksiTidakDapatDirefund   = errors.New("refund hanya dapat diajukan untuk transaksi yang sudah dibayar")
+	ErrStatusRefundTidakValid        = errors.New("perubahan status refund tidak valid")
+)
+
+// refundableStatuses adalah status (sub-)transaksi yang itemnya boleh diajukan refund
+var refundableStatuses = map[string]bool{
+	models.TrxStatusPaid:       true,
+	models.TrxStatusProcessing: true,
+	models.TrxStatusShipped:    true,
+	models.TrxStatusDelivered:  true,
+	models.TrxStatusCompleted:  true,
+}
+
+// refundTransitions berisi tabel transisi status refund: status asal -> status tujuan -> aktor yang diizinkan.
+// Admin dapat menyetujui refund yang sudah ditolak penjual.
+var refundTransitions = map[string]map[string][]string{
+	models.RefundStatusRequested: {
+		models.RefundStatusApproved: {AktorSeller, AktorAdmin},
+		models.RefundStatusRejected: {AktorSeller, AktorAdmin},
+	},
+	models.RefundStatusRejected: {
+		models.RefundStatusApproved: {AktorAdmin},
+	},
+	models.RefundStatusApproved: {
+		models.RefundStatusRefunded: {AktorAdmin, AktorSystem},
+	},
+}
+
+// RefundRequest adalah data pengajuan refund oleh pembeli
+type RefundRequest struct {
+	IDDetailTrx uint   `json:"id_detail_trx"`
+	Kuantitas   int    `json:"kuantitas"`
+	Alasan      string `json:"alasan"`
+}
+
+// RefundDecision adalah keputusan penjual atau admin atas pengajuan refund
+type RefundDecision struct {
+	Restock bool   `json:"restock"` // kembalikan unit ke stok produk saat refund disetujui
+	Catatan string `json:"catatan"`
+}
+
+// refundableQuantity menghitung sisa kuantitas detail transaksi yang masih bisa diajukan refund
+func refundableQuantity(tx *gorm.DB, detail *models.DetailTransaction) int {
+	var requested int64
+	tx.Model(&models.Refund{}).
+		Where("id_detail_trx = ? AND status = ?", detail.ID, models.RefundStatusRequested).
+		Select("COALESCE(SUM(kuantitas), 0)").Scan(&requested)
+	return detail.Kuantitas - detail.KuantitasRefund - int(requested)
+}
+
+// lineDiscount mengembalikan potongan voucher yang jatuh ke satu detail transaksi. Transaksi yang
+// dibuat sebelum potongan dicatat per baris membagi potongan sub-transaksinya sebanding nilai baris.
+func lineDiscount(tx *gorm.DB, detail *models.DetailTransaction) (int, error) {
+	if detail.Diskon > 0 || detail.IDSubTrx == 0 {
+		return detail.Diskon, nil
+	}
+
+	var sub models.SubTransaction
+	if err := tx.Select("id", "diskon").First(&sub, detail.IDSubTrx).Error; err != nil {
+		return 0, err
+	}
+	if sub.Diskon == 0 {
+		return 0, nil
+	}
+
+	var details []models.DetailTransaction
+	if err := tx.Select("id", "harga_satuan", "kuantitas", "diskon").
+		Where("id_sub_trx = ?", sub.ID).Order("id ASC").Find(&details).Error; err != nil {
+		return 0, err
+	}
+	gross := 0
+	for _, d := range details {
+		// Potongan sudah dicatat per baris dan baris ini tidak mendapat potongan
+		if d.Diskon > 0 {
+			return 0, nil
+		}
+		gross += d.HargaSatuan * d.Kuantitas
+	}
+	if gross == 0 {
+		return 0, nil
+	}
+
+	remaining := sub.Diskon
+	for i, d := range details {
+		share := sub.Diskon * d.HargaSatuan * d.Kuantitas / gross
+		if i == len(details)-1 {
+			share = remaining
+		}
+		if d.ID == detail.ID {
+			return share, nil
+		}
+		remaining -= share
+	}
+	return 0, nil
+}
+
+// refundAmount menghitung nilai refund dari nilai yang benar-benar dibayar untuk baris tersebut,
+// yaitu harga dikurangi potongan voucher. Refund yang menghabiskan sisa kuantitas mendapat seluruh
+// sisa nilai, sehingga total refund satu baris tidak pernah melebihi yang dibayar.
+func refundAmount(tx *gorm.DB, detail *models.DetailTransaction, kuantitas int) (int, error) {
+	diskon, err := lineDiscount(tx, detail)
+	if err != nil {
+		return 0, err
+	}
+	paid := detail.HargaSatuan*detail.Kuantitas - diskon
+
+	var refunded int64
+	tx.Model(&models.Refund{}).
+		Where("id_detail_trx = ? AND status IN ?", detail.ID,
+			[]string{models.RefundStatusRequested, models.RefundStatusApproved, models.RefundStatusRefunded}).
+		Select("COALESCE(SUM(jumlah), 0)").Scan(&refunded)
+	remaining := paid - int(refunded)
+
+	jumlah := paid * kuantitas / detail.Kuantitas
+	if kuantitas >= refundableQuantity(tx, detail) || jumlah > remaining {
+		jumlah = remaining
+	}
+	if jumlah < 0 {
+		jumlah = 0
+	}
+	return jumlah, nil
+}
+
+// lockDetailTransaction mengambil detail transaksi dengan row lock
+func lockDetailTransaction(tx *gorm.DB, detailID, trxID uint) (*models.DetailTransaction, error) {
+	var detail models.DetailTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("LogProduct").
+		Where("id = ? AND id_trx = ?", detailID, trxID).First(&detail).Error; err != nil {
+		return nil, ErrDetailTransaksiTidakDitemukan
+	}
+	return &detail, nil
+}
+
+// CreateRefund membuat pengajuan refund untuk sebagian atau seluruh kuantitas satu item transaksi
+func CreateRefund(trxID, userID uint, req RefundRequest) (*models.Refund, error) {
+	req.Alasan = strings.TrimSpace(req.Alasan)
+	if req.Alasan == "" {
+		return nil, ErrAlasanRefundKosong
+	}
+	if req.Kuantitas <= 0 {
+		return nil, ErrKuantitasTidakValid
+	}
+
+	var refund models.Refund
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		trx, err := LockTransaction(tx, trxID)
+		if err != nil {
+			return err
+		}
+		if trx.IDUser != userID {
+			return ErrTransaksiTidakDitemukan
+		}
+
+		detail, err := lockDetailTransaction(tx, req.IDDetailTrx, trx.ID)
+		if err != nil {
+			return err
+		}
+
+		// Status yang menentukan adalah status sub-transaksi tempat item berada
+		status := trx.Status
+		if detail.IDSubTrx != 0 {
+			var sub models.SubTransaction
+			if err := tx.First(&sub, detail.IDSubTrx).Error; err == nil {
+				status = sub.Status
+			}
+		}
+		if !refundableStatuses[status] {
+			return ErrTransaksiTidakDapatDirefund
+		}
+
+		if req.Kuantitas > refundableQuantity(tx, detail) {
+			return ErrKuantitasRefundMelebihi
+		}
+
+		jumlah, err := refundAmount(tx, detail, req.Kuantitas)
+		if err != nil {
+			return err
+		}
+
+		// Pada dropship, margin reseller ikut dikurangi sebanding dengan unit yang dikembalikan
+		marginPerUnit := 0
+		if detail.HargaJual > 0 {
+			marginPerUnit = detail.HargaJual - detail.HargaSatuan
+		}
+
+		refund = models.Refund{
+			IDTrx:       trx.ID,
+			IDSubTrx:    detail.IDSubTrx,
+			IDDetailTrx: detail.ID,
+			IDToko:      detail.IDToko,
+			IDUser:      userID,
+			Kuantitas:   req.Kuantitas,
+			Jumlah:      jumlah,
+			Margin:      marginPerUnit * req.Kuantitas,
+			Alasan:      req.Alasan,
+			Status:      models.RefundStatusRequested,
+		}
+		if err := tx.Create(&refund).Error; err != nil {
+			return errors.New("gagal menyimpan refund")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// applyRefund mengurangi nilai item, sub-transaksi dan transaksi sebesar nilai refund,
+// lalu mengembalikan stok jika diminta
+func applyRefund(tx *gorm.DB, refund *models.Refund) error {
+	detail, err := lockDetailTransaction(tx, refund.IDDetailTrx, refund.IDTrx)
+	if err != nil {
+		return err
+	}
+	if refund.Kuantitas > detail.Kuantitas-detail.KuantitasRefund {
+		return ErrKuantitasRefundMelebihi
+	}
+
+	// Nilai refund tidak boleh melebihi sisa nilai baris yang dibayar, termasuk untuk pengajuan
+	// lama yang nilainya belum memperhitungkan potongan voucher
+	diskon, err := lineDiscount(tx, detail)
+	if err != nil {
+		return err
+	}
+	if sisa := detail.HargaTotal - diskon; refund.Jumlah > sisa {
+		refund.Jumlah = max(sisa, 0)
+		if err := tx.Model(&models.Refund{}).Where("id = ?", refund.ID).Update("jumlah", refund.Jumlah).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.DetailTransaction{}).Where("id = ?", detail.ID).Updates(map[string]interface{}{
+		"kuantitas_refund": gorm.Expr("kuantitas_refund + ?", refund.Kuantitas),
+		"harga_total":      gorm.Expr("harga_total - ?", refund.Jumlah),
+		"margin":           gorm.Expr("margin - ?", refund.Margin),
+	}).Error; err != nil {
+		return err
+	}
+
+	if refund.IDSubTrx != 0 {
+		if err := tx.Model(&models.SubTransaction{}).Where("id = ?", refund.IDSubTrx).Updates(map[string]interface{}{
+			"subtotal":     gorm.Expr("subtotal - ?", refund.Jumlah),
+			"total_refund": gorm.Expr("total_refund + ?", refund.Jumlah),
+		}).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&models.Transaction{}).Where("id = ?", refund.IDTrx).Updates(map[string]interface{}{
+		"harga_total":  gorm.Expr("harga_total - ?", refund.Jumlah),
+		"total_refund": gorm.Expr("total_refund + ?", refund.Jumlah),
+	}).Error; err != nil {
+		return err
+	}
+
+	if refund.Restock {
+		trxID := refund.IDTrx
+		return ReleaseStock(tx, detail.LogProduct.IDProduk, detail.IDVarian, refund.Kuantitas, StockChange{
+			Tipe:      models.MovementRefund,
+			Alasan:    refund.Alasan,
+			Aktor:     refund.AktorPutusan,
+			IDUser:    refund.DiputuskanOleh,
+			IDTrx:     &trxID,
+			Referensi: fmt.Sprintf("REFUND-%d", refund.ID),
+		})
+	}
+	return nil
+}
+
+// closeOpenRefunds menolak pengajuan refund yang masih menunggu keputusan saat transaksi, atau
+// sub-transaksi jika subTrxID diisi, dibatalkan atau kadaluarsa
+func closeOpenRefunds(tx *gorm.DB, trxID uint, subTrxID *uint, aktor string, userID *uint, catatan string) error {
+	query := tx.Model(&models.Refund{}).Where("id_trx = ? AND status = ?", trxID, models.RefundStatusRequested)
+	if subTrxID != nil {
+		query = query.Where("id_sub_trx = ?", *subTrxID)
+	}
+	return query.Updates(map[string]interface{}{
+		"status":          models.RefundStatusRejected,
+		"diputuskan_oleh": userID,
+		"aktor_putusan":   aktor,
+		"catatan_putusan": catatan,
+		"diputuskan_pada": time.Now(),
+	}).Error
+}
+
+// refundRoles menentukan peran actor terhadap sebuah refund
+func refundRoles(actor *Actor, refund *models.Refund) []string {
+	var roles []string
+	if refund.IDUser == actor.UserID {
+		roles = append(roles, AktorBuyer)
+	}
+	if actor.OwnsStore(refund.IDToko) {
+		roles = append(roles, AktorSeller)
+	}
+	if actor.IsAdmin {
+		roles = append(roles, AktorAdmin)
+	}
+	return roles
+}
+
+// TransitionRefund memindahkan status refund atas nama penjual atau admin. Persetujuan
+// langsung menyesuaikan nilai transaksi dan stok dalam DB transaction yang sama.
+func TransitionRefund(refundID, userID uint, to string, decision RefundDecision) (*models.Refund, error) {
+	var refund models.Refund
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Transaksi dikunci lebih dulu dengan urutan yang sama seperti pembatalan, agar refund tidak
+		// disetujui bersamaan dengan pembatalan transaksinya
+		if err := tx.Select("id", "id_trx").First(&refund, refundID).Error; err != nil {
+			return ErrRefundTidakDitemukan
+		}
+		trx, err := LockTransaction(tx, refund.IDTrx)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&refund, refundID).Error; err != nil {
+			return ErrRefundTidakDitemukan
+		}
+
+		actor, err := resolveActor(tx, userID)
+		if err != nil {
+			return err
+		}
+		roles := refundRoles(actor, &refund)
+		if len(roles) == 0 {
+			return ErrRefundTidakDitemukan
+		}
+
+		allowed, ok := refundTransitions[refund.Status][to]
+		if !ok {
+			return ErrStatusRefundTidakValid
+		}
+		aktor, ok := pickActor(roles, allowed)
+		if !ok {
+			return ErrAksesTransaksiDitolak
+		}
+
+		// Item dari transaksi yang sudah dibatalkan atau kadaluarsa tidak boleh direfund lagi
+		// karena stok dan nilainya sudah dikembalikan oleh pembatalan
+		if to == models.RefundStatusApproved {
+			status := trx.Status
+			if refund.IDSubTrx != 0 {
+				var sub models.SubTransaction
+				if err := tx.Select("id", "status").First(&sub, refund.IDSubTrx).Error; err == nil {
+					status = sub.Status
+				}
+			}
+			if !refundableStatuses[status] {
+				return ErrTransaksiTidakDapatDirefund
+			}
+		}
+
+		now := time.Now()
+		updates := map[string]interface{}{"status": to}
+		if to == models.RefundStatusRefunded {
+			updates["dikembalikan_pada"] = now
+			refund.DikembalikanPada = &now
+		} else {
+			updates["diputuskan_oleh"] = userID
+			updates["aktor_putusan"] = aktor
+			updates["catatan_putusan"] = strings.TrimSpace(decision.Catatan)
+			updates["diputuskan_pada"] = now
+			updates["restock"] = decision.Restock && to == models.RefundStatusApproved
+			refund.DiputuskanOleh = &userID
+			refund.AktorPutusan = aktor
+			refund.CatatanPutusan = strings.TrimSpace(decision.Catatan)
+			refund.DiputuskanPada = &now
+			refund.Restock = decision.Restock && to == models.RefundStatusApproved
+		}
+
+		from := refund.Status
+		result := tx.Model(&models.Refund{}).Where("id = ? AND status = ?", refund.ID, from).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusRefundTidakValid
+		}
+		refund.Status = to
+
+		if to == models.RefundStatusApproved {
+			return applyRefund(tx, &refund)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+// GetTransactionRefunds mengambil refund sebuah transaksi. Penjual yang bukan pembeli hanya
+// melihat refund untuk item tokonya.
+func GetTransactionRefunds(trxID, userID uint) ([]models.Refund, error) {
+	var trx models.Transaction
+	if err := config.DB.First(&trx, trxID).Error; err != nil {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanViewTransaction(config.DB, &trx) {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+
+	query := config.DB.Preload("DetailTrx.LogProduct").Where("id_trx = ?", trx.ID)
+	if !actor.IsAdmin && trx.IDUser != actor.UserID {
+		query = query.Where("id_toko = ?", actor.TokoID)
+	}
+
+	refunds := []models.Refund{}
+	if err := query.Order("id DESC").Find(&refunds).Error; err != nil {
+		return nil, errors.New("gagal mengambil refund")
+	}
+	return refunds, nil
+}
+
+// GetRefunds mengambil daftar refund yang terkait dengan user dengan pagination: refund yang
+// diajukan user dan refund untuk toko user, atau semua refund untuk admin
+func GetRefunds(userID uint, status string, page, limit int) ([]models.Refund, int64, int, error) {
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	query := config.DB.Model(&models.Refund{})
+	if !actor.IsAdmin {
+		if actor.TokoID != 0 {
+			query = query.Where("id_user = ? OR id_toko = ?", actor.UserID, actor.TokoID)
+		} else {
+			query = query.Where("id_user = ?", actor.UserID)
+		}
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	refunds := []models.Refund{}
+	offset := (page - 1) * limit
+	if err := query.Preload("DetailTrx.LogProduct").
+		Order("id DESC").Limit(limit).Offset(offset).Find(&refunds).Error; err != nil {
+		return nil, 0, 0, errors.New("gagal mengambil refund")
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	return refunds, total, totalPages, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// ApplyReseller mencatat pengajuan user untuk menjadi reseller
+func ApplyReseller(userID uint) (*models.User, error) {
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+
+	if user.IsVerifiedReseller() {
+		return nil, errors.New("user sudah terverifikasi sebagai reseller")
+	}
+
+	if err := config.DB.Model(&user).Updates(map[string]interface{}{
+		"is_reseller":     true,
+		"status_reseller": models.ResellerStatusPending,
+	}).Error; err != nil {
+		return nil, errors.New("gagal mengajukan reseller")
+	}
+
+	return &user, nil
+}
+
+// VerifyReseller menyetujui atau menolak pengajuan reseller (Admin Only)
+func VerifyReseller(userID uint, status string) (*models.User, error) {
+	if status != models.ResellerStatusVerified && status != models.ResellerStatusRejected {
+		return nil, errors.New("status reseller harus verified atau rejected")
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		return nil, errors.New("user tidak ditemukan")
+	}
+
+	if !user.IsReseller {
+		return nil, errors.New("user belum mengajukan sebagai reseller")
+	}
+
+	if err := config.DB.Model(&user).Update("status_reseller", status).Error; err != nil {
+		return nil, errors.New("gagal memperbarui status reseller")
+	}
+
+	return &user, nil
+}
+
+// ResellerMarginOrder adalah ringkasan margin reseller untuk satu pesanan dropship
+type ResellerMarginOrder struct {
+	IDTrx        uint      `json:"id_trx"`
+	KodeInvoice  string    `json:"kode_invoice"`
+	Status       string    `json:"status"`
+	DropshipNama string    `json:"dropship_nama"`
+	HargaModal   int       `json:"harga_modal"`
+	HargaJual    int       `json:"harga_jual"`
+	Margin       int       `json:"margin"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ResellerMarginReport adalah laporan margin reseller untuk keperluan payout
+type ResellerMarginReport struct {
+	MarginSiapCair int                   `json:"margin_siap_cair"` // dari pesanan yang sudah selesai
+	MarginTertunda int                   `json:"margin_tertunda"`  // dari pesanan yang masih berjalan
+	Pesanan        []ResellerMarginOrder `json:"pesanan"`
+}
+
+// GetResellerMarginReport menghitung margin reseller dari pesanan dropship dalam rentang tanggal
+func GetResellerMarginReport(userID uint, dateFrom, dateTo *time.Time) (*ResellerMarginReport, error) {
+	query := config.DB.Where("id_user = ? AND is_dropship = ? AND status NOT IN ?", userID, true,
+		[]string{models.TrxStatusCancelled, models.TrxStatusExpired})
+	if dateFrom != nil {
+		query = query.Where("created_at >= ?", *dateFrom)
+	}
+	if dateTo != nil {
+		query = query.Where("created_at < ?", dateTo.AddDate(0, 0, 1))
+	}
+
+	// Baris milik sub-transaksi yang dibatalkan atau kadaluarsa tidak menghasilkan margin
+	inactive := config.DB.Model(&models.SubTransaction{}).Select("id").
+		Where("status IN ?", []string{models.TrxStatusCancelled, models.TrxStatusExpired})
+
+	var transactions []models.Transaction
+	if err := query.Preload("DetailTransaksi", "id_sub_trx NOT IN (?)", inactive).
+		Order("created_at DESC").Find(&transactions).Error; err != nil {
+		return nil, errors.New("gagal mengambil laporan margin reseller")
+	}
+
+	report := &ResellerMarginReport{Pesanan: []ResellerMarginOrder{}}
+	for _, trx := range transactions {
+		order := ResellerMarginOrder{
+			IDTrx:        trx.ID,
+			KodeInvoice:  trx.KodeInvoice,
+			Status:       trx.Status,
+			DropshipNama: trx.DropshipNama,
+			CreatedAt:    trx.CreatedAt,
+		}
+		for _, detail := range trx.DetailTransaksi {
+			// Margin dihitung dari unit yang tidak direfund, sebanding dengan pengurangan saat refund
+			kuantitas := detail.Kuantitas - detail.KuantitasRefund
+			if kuantitas <= 0 {
+				continue
+			}
+			order.HargaModal += detail.HargaTotal
+			order.HargaJual += detail.HargaJual * kuantitas
+			if detail.HargaJual > 0 {
+				order.Margin += (detail.HargaJual - detail.HargaSatuan) * kuantitas
+			}
+		}
+
+		if trx.Status == models.TrxStatusCompleted {
+			report.MarginSiapCair += order.Margin
+		} else {
+			report.MarginTertunda += order.Margin
+		}
+		report.Pesanan = append(report.Pesanan, order)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// SellerOrderFilter berisi filter untuk daftar pesanan masuk penjual
+type SellerOrderFilter struct {
+	Status   string
+	Search   string
+	DateFrom *time.Time
+	DateTo   *time.Time
+	Page     int
+	Limit    int
+}
+
+// SellerOrder adalah pesanan yang dilihat dari sisi penjual, hanya berisi item milik tokonya
+type SellerOrder struct {
+	ID              uint                       `json:"id"`
+	KodeInvoice     string                     `json:"kode_invoice"`
+	StatusTransaksi string                     `json:"status_transaksi"`
+	MethodBayar     string                     `json:"method_bayar"`
+	AlamatKirim     models.Alamat              `json:"alamat_kirim"`
+	SubTransaksi    *models.SubTransaction     `json:"sub_transaksi"`
+	DetailTransaksi []models.DetailTransaction `json:"detail_transaksi"`
+	Subtotal        int                        `json:"subtotal"`
+	CreatedAt       time.Time                  `json:"created_at"`
+}
+
+// GetSellerOrders mengambil pesanan yang berisi produk dari toko milik user
+func GetSellerOrders(userID uint, filter SellerOrderFilter) ([]SellerOrder, int64, int, error) {
+	toko, err := GetStoreByUserID(userID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	query := config.DB.Model(&models.Transaction{}).
+		Where("id IN (?)", config.DB.Model(&models.DetailTransaction{}).Select("id_trx").Where("id_toko = ?", toko.ID))
+
+	if filter.Status != "" {
+		query = query.Where("id IN (?)", config.DB.Model(&models.SubTransaction{}).
+			Select("id_trx").Where("id_toko = ? AND status = ?", toko.ID, filter.Status))
+	}
+	if filter.Search != "" {
+		query = query.Where("kode_invoice LIKE ?", "%"+filter.Search+"%")
+	}
+	if filter.DateFrom != nil {
+		query = query.Where("created_at >= ?", *filter.DateFrom)
+	}
+	if filter.DateTo != nil {
+		query = query.Where("created_at < ?", filter.DateTo.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var transactions []models.Transaction
+	offset := (filter.Page - 1) * filter.Limit
+	err = query.
+		Preload("Alamat").
+		Preload("SubTransaksi", "id_toko = ?", toko.ID).
+		Preload("DetailTransaksi", "id_toko = ?", toko.ID).
+		Preload("DetailTransaksi.LogProduct").
+		Order("created_at DESC").Limit(filter.Limit).Offset(offset).
+		Find(&transactions).Error
+	if err != nil {
+		return nil, 0, 0, errors.New("gagal mengambil pesanan toko")
+	}
+
+	orders := make([]SellerOrder, 0, len(transactions))
+	for _, trx := range transactions {
+		order := SellerOrder{
+			ID:              trx.ID,
+			KodeInvoice:     trx.KodeInvoice,
+			StatusTransaksi: trx.Status,
+			MethodBayar:     trx.MethodBayar,
+			AlamatKirim:     trx.Alamat,
+			DetailTransaksi: trx.DetailTransaksi,
+			CreatedAt:       trx.CreatedAt,
+		}
+		if len(trx.SubTransaksi) > 0 {
+			order.SubTransaksi = &trx.SubTransaksi[0]
+		}
+		for _, detail := range trx.DetailTransaksi {
+			order.Subtotal += detail.HargaTotal
+		}
+		orders = append(orders, order)
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(filter.Limit)))
+	return orders, total, totalPages, nil
+}
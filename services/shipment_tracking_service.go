@@ -0,0 +1,247 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// trackingRefreshAge adalah umur minimum data pelacakan sebelum diambil ulang saat dilihat pembeli
+const trackingRefreshAge = 15 * time.Minute
+
+// ShipmentTracking adalah ringkasan pengiriman satu sub-transaksi beserta riwayat kejadiannya
+type ShipmentTracking struct {
+	IDSubTrx    uint                   `json:"id_sub_trx"`
+	KodeInvoice string                 `json:"kode_invoice"`
+	IDToko      uint                   `json:"id_toko"`
+	Status      string                 `json:"status"`
+	Kurir       string                 `json:"kurir"`
+	NamaKurir   string                 `json:"nama_kurir"`
+	NoResi      string                 `json:"no_resi"`
+	DikirimPada *time.Time             `json:"dikirim_pada"`
+	DilacakPada *time.Time             `json:"dilacak_pada"`
+	Events      []models.TrackingEvent `json:"events"`
+}
+
+// recordShipmentEvent mencatat kejadian pengiriman saat penjual mengirim paket
+func recordShipmentEvent(tx *gorm.DB, sub *models.SubTransaction, waktu time.Time) error {
+	event := models.TrackingEvent{
+		IDSubTrx:      sub.ID,
+		Status:        models.TrackingStatusShipped,
+		WaktuKejadian: waktu,
+		Deskripsi:     fmt.Sprintf("Paket diserahkan ke %s dengan nomor resi %s", SupportedCouriers[sub.Kurir], sub.NoResi),
+		Sumber:        AktorSeller,
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event).Error
+}
+
+// recordTrackingUpdates menyimpan kejadian dari kurir. Kejadian yang sudah tercatat dilewati.
+// Mengembalikan true jika kurir melaporkan paket sudah diterima.
+func recordTrackingUpdates(tx *gorm.DB, sub *models.SubTransaction, sumber string, updates []TrackingUpdate) (bool, error) {
+	delivered := false
+	for _, u := range updates {
+		event := models.TrackingEvent{
+			IDSubTrx:      sub.ID,
+			Status:        u.Status,
+			WaktuKejadian: u.Waktu,
+			Deskripsi:     u.Deskripsi,
+			Lokasi:        u.Lokasi,
+			Sumber:        sumber,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&event).Error; err != nil {
+			return false, err
+		}
+		if u.Status == models.TrackingStatusDelivered {
+			delivered = true
+		}
+	}
+	return delivered, nil
+}
+
+// RefreshShipmentTracking mengambil status terbaru paket dari kurir dan menyimpannya. Jika kurir
+// melaporkan paket sudah diterima, sub-transaksi dipindahkan ke delivered oleh sistem.
+// API kurir dipanggil di luar DB transaction agar row lock tidak tertahan selama request keluar.
+func RefreshShipmentTracking(provider CourierProvider, subID uint) error {
+	var sub models.SubTransaction
+	if err := config.DB.First(&sub, subID).Error; err != nil {
+		return ErrTransaksiTidakDitemukan
+	}
+	if sub.Status != models.TrxStatusShipped {
+		return nil
+	}
+
+	updates, err := provider.Track(&sub)
+	if err != nil {
+		return err
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		// Urutan lock sama dengan perpindahan status: transaksi dulu, lalu sub-transaksi
+		trx, err := LockTransaction(tx, sub.IDTrx)
+		if err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&sub, subID).Error; err != nil {
+			return err
+		}
+
+		delivered, err := recordTrackingUpdates(tx, &sub, provider.Name(), updates)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&sub).Update("dilacak_pada", time.Now()).Error; err != nil {
+			return err
+		}
+
+		if !delivered || sub.Status != models.TrxStatusShipped {
+			return nil
+		}
+		catatan := "Paket diterima menurut " + SupportedCouriers[sub.Kurir]
+		if err := applySubTransactionStatus(tx, &sub, models.TrxStatusDelivered, AktorSystem, nil,
+			TransitionInput{Catatan: catatan}); err != nil {
+			return err
+		}
+		return syncTransactionStatus(tx, trx, AktorSystem, nil, catatan)
+	})
+}
+
+// GetTransactionTracking mengambil pengiriman setiap sub-transaksi yang boleh dilihat user.
+// Paket yang sedang dikirim diperbarui dari kurir jika datanya sudah lama; kegagalan kurir
+// tidak menggagalkan request dan riwayat yang tersimpan tetap dikembalikan.
+func GetTransactionTracking(trxID, userID uint) ([]ShipmentTracking, error) {
+	var trx models.Transaction
+	if err := config.DB.Preload("SubTransaksi").First(&trx, trxID).Error; err != nil {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanViewTransaction(config.DB, &trx) {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+
+	subs := actor.VisibleSubTransactions(&trx)
+	if provider, err := GetCourierProvider(); err == nil {
+		for _, sub := range subs {
+			if sub.Status != models.TrxStatusShipped ||
+				(sub.DilacakPada != nil && time.Since(*sub.DilacakPada) < trackingRefreshAge) {
+				continue
+			}
+			if err := RefreshShipmentTracking(provider, sub.ID); err != nil {
+				log.Printf("Gagal memperbarui pelacakan %s: %v", sub.KodeInvoice, err)
+			}
+		}
+	}
+
+	subIDs := make([]uint, 0, len(subs))
+	for _, sub := range subs {
+		subIDs = append(subIDs, sub.ID)
+	}
+
+	var fresh []models.SubTransaction
+	if err := config.DB.Preload("TrackingEvents", func(db *gorm.DB) *gorm.DB {
+		return db.Order("waktu_kejadian ASC, id ASC")
+	}).Where("id IN ?", subIDs).Order("id ASC").Find(&fresh).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]ShipmentTracking, 0, len(fresh))
+	for _, sub := range fresh {
+		result = append(result, ShipmentTracking{
+			IDSubTrx:    sub.ID,
+			KodeInvoice: sub.KodeInvoice,
+			IDToko:      sub.IDToko,
+			Status:      sub.Status,
+			Kurir:       sub.Kurir,
+			NamaKurir:   SupportedCouriers[sub.Kurir],
+			NoResi:      sub.NoResi,
+			DikirimPada: sub.DikirimPada,
+			DilacakPada: sub.DilacakPada,
+			Events:      sub.TrackingEvents,
+		})
+	}
+	return result, nil
+}
+
+// ShipmentTrackingConfig mengatur scheduler polling status pengiriman ke kurir
+type ShipmentTrackingConfig struct {
+	Interval  time.Duration // jeda antar polling
+	BatchSize int           // jumlah sub-transaksi maksimal per polling
+}
+
+// LoadShipmentTrackingConfig membaca konfigurasi polling dari environment variable
+func LoadShipmentTrackingConfig() ShipmentTrackingConfig {
+	cfg := ShipmentTrackingConfig{
+		Interval:  30 * time.Minute,
+		BatchSize: 100,
+	}
+
+	if v, err := time.ParseDuration(os.Getenv("COURIER_POLL_INTERVAL")); err == nil && v > 0 {
+		cfg.Interval = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("COURIER_POLL_BATCH_SIZE")); err == nil && v > 0 {
+		cfg.BatchSize = v
+	}
+
+	return cfg
+}
+
+// PollShipments memperbarui pelacakan sub-transaksi yang sedang dikirim, dimulai dari yang
+// paling lama tidak diperbarui. Kegagalan satu paket dicatat dan dilewati.
+func PollShipments(provider CourierProvider, cfg ShipmentTrackingConfig) (int, error) {
+	var subs []models.SubTransaction
+	if err := config.DB.Where("status = ?", models.TrxStatusShipped).
+		Order("dilacak_pada ASC, id ASC").
+		Limit(cfg.BatchSize).Find(&subs).Error; err != nil {
+		return 0, err
+	}
+
+	polled := 0
+	for _, sub := range subs {
+		if err := RefreshShipmentTracking(provider, sub.ID); err != nil {
+			log.Printf("Gagal memperbarui pelacakan %s: %v", sub.KodeInvoice, err)
+			continue
+		}
+		polled++
+	}
+	return polled, nil
+}
+
+// StartShipmentTrackingScheduler menjalankan PollShipments secara berkala di background jika
+// penyedia pelacakan kurir dikonfigurasi. Fungsi yang dikembalikan dipakai untuk menghentikannya.
+func StartShipmentTrackingScheduler() func() {
+	provider, err := GetCourierProvider()
+	if err != nil {
+		log.Println("Scheduler pelacakan pengiriman dinonaktifkan:", err)
+		return func() {}
+	}
+
+	cfg := LoadShipmentTrackingConfig()
+	ticker := time.NewTicker(cfg.Interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := PollShipments(provider, cfg); err != nil {
+					log.Printf("Scheduler pelacakan pengiriman gagal: %v", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
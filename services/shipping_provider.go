@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/habbazettt/evermos-service-go/models"
+)
+
+// Nama penyedia tarif ongkos kirim
+const (
+	ShippingProviderRateCard = "rate_card"
+)
+
+// BeratDefaultGram dipakai untuk produk yang belum memiliki berat
+const BeratDefaultGram = 1000
+
+var ErrProviderOngkirTidakDikenal = errors.New("penyedia ongkos kirim tidak dikenal")
+
+// Lokasi adalah titik asal atau tujuan pengiriman, memakai ID wilayah dari endpoint provinsi/kota
+type Lokasi struct {
+	IDProvinsi string `json:"id_provinsi"`
+	IDKota     string `json:"id_kota"`
+}
+
+// ShippingRate adalah hasil perhitungan ongkos kirim satu paket
+type ShippingRate struct {
+	Layanan  string `json:"layanan"`
+	Ongkir   int    `json:"ongkir"`
+	Estimasi string `json:"estimasi"`
+}
+
+// ShippingRateProvider adalah kontrak yang harus dipenuhi setiap penyedia tarif ongkos kirim
+type ShippingRateProvider interface {
+	// Name mengembalikan nama penyedia
+	Name() string
+	// Rate menghitung ongkos kirim dari asal ke tujuan untuk berat dalam gram
+	Rate(asal, tujuan Lokasi, beratGram int) (*ShippingRate, error)
+}
+
+var shippingRateProviders = map[string]ShippingRateProvider{
+	ShippingProviderRateCard: &RateCardProvider{},
+}
+
+// GetShippingRateProvider mengambil penyedia tarif yang dipilih lewat SHIPPING_PROVIDER (default rate_card)
+func GetShippingRateProvider() (ShippingRateProvider, error) {
+	name := strings.ToLower(strings.TrimSpace(os.Getenv("SHIPPING_PROVIDER")))
+	if name == "" {
+		name = ShippingProviderRateCard
+	}
+
+	provider, ok := shippingRateProviders[name]
+	if !ok {
+		return nil, ErrProviderOngkirTidakDikenal
+	}
+	return provider, nil
+}
+
+// lokasiToko mengambil lokasi asal pengiriman toko
+func lokasiToko(toko *models.Toko) Lokasi {
+	return Lokasi{IDProvinsi: toko.IDProvinsi, IDKota: toko.IDKota}
+}
+
+// lokasiAlamat mengambil lokasi tujuan pengiriman dari alamat pembeli
+func lokasiAlamat(alamat *models.Alamat) Lokasi {
+	return Lokasi{IDProvinsi: alamat.IDProvinsi, IDKota: alamat.IDKota}
+}
+
+// beratProduk mengembalikan berat produk dalam gram, atau berat default jika belum diisi
+func beratProduk(produk *models.Produk) int {
+	if produk.Berat > 0 {
+		return produk.Berat
+	}
+	return BeratDefaultGram
+}
+
+// beratLines menjumlahkan berat semua baris checkout dalam gram
+func beratLines(lines []checkoutLine) int {
+	total := 0
+	for _, line := range lines {
+		total += beratProduk(&line.Produk) * line.Kuantitas
+	}
+	return total
+}
+
+// shippingFeeForStore menghitung ongkos kirim satu toko ke alamat pembeli berdasarkan total berat barisnya
+func shippingFeeForStore(provider ShippingRateProvider, toko *models.Toko, alamat *models.Alamat, lines []checkoutLine) (*ShippingRate, int, error) {
+	berat := beratLines(lines)
+	rate, err := provider.Rate(lokasiToko(toko), lokasiAlamat(alamat), berat)
+	if err != nil {
+		return nil, berat, err
+	}
+	return rate, berat, nil
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+)
+
+// Zona pengiriman berdasarkan kesamaan kota dan provinsi asal dengan tujuan
+const (
+	ZonaDalamKota     = "dalam_kota"
+	ZonaDalamProvinsi = "dalam_provinsi"
+	ZonaAntarProvinsi = "antar_provinsi"
+)
+
+// RateCardRule adalah satu baris tabel tarif. Field asal/tujuan/zona yang kosong berlaku untuk semua.
+type RateCardRule struct {
+	AsalProvinsi   string `json:"asal_provinsi"`
+	TujuanProvinsi string `json:"tujuan_provinsi"`
+	Zona           string `json:"zona"`
+	HargaPerKg     int    `json:"harga_per_kg"`
+	HargaMinimum   int    `json:"harga_minimum"`
+	Estimasi       string `json:"estimasi"`
+}
+
+// defaultRateCard adalah tarif dasar per zona, dipakai jika tidak ada aturan yang lebih spesifik
+var defaultRateCard = []RateCardRule{
+	{Zona: ZonaDalamKota, HargaPerKg: 9000, HargaMinimum: 9000, Estimasi: "1-2 hari"},
+	{Zona: ZonaDalamProvinsi, HargaPerKg: 14000, HargaMinimum: 14000, Estimasi: "2-3 hari"},
+	{Zona: ZonaAntarProvinsi, HargaPerKg: 24000, HargaMinimum: 24000, Estimasi: "3-5 hari"},
+}
+
+// RateCardProvider menghitung ongkos kirim dari tabel tarif lokal. Aturan tambahan dapat diisi
+// lewat SHIPPING_RATE_CARD (JSON array RateCardRule) dan dicek sebelum tarif dasar; aturan
+// pertama yang cocok yang dipakai. Berat dibulatkan ke atas per kilogram.
+type RateCardProvider struct{}
+
+func (p *RateCardProvider) Name() string {
+	return ShippingProviderRateCard
+}
+
+func (p *RateCardProvider) Rate(asal, tujuan Lokasi, beratGram int) (*ShippingRate, error) {
+	zona := shippingZone(asal, tujuan)
+	rule := matchRateCardRule(rateCardRules(), asal, tujuan, zona)
+
+	kg := (beratGram + 999) / 1000
+	if kg < 1 {
+		kg = 1
+	}
+
+	ongkir := kg * rule.HargaPerKg
+	if ongkir < rule.HargaMinimum {
+		ongkir = rule.HargaMinimum
+	}
+
+	return &ShippingRate{
+		Layanan:  zona,
+		Ongkir:   ongkir,
+		Estimasi: rule.Estimasi,
+	}, nil
+}
+
+// shippingZone menentukan zona pengiriman. Lokasi yang belum lengkap dihitung sebagai
+// antar provinsi agar ongkos kirim tidak pernah lebih rendah dari seharusnya.
+func shippingZone(asal, tujuan Lokasi) string {
+	if asal.IDProvinsi == "" || tujuan.IDProvinsi == "" || asal.IDProvinsi != tujuan.IDProvinsi {
+		return ZonaAntarProvinsi
+	}
+	if asal.IDKota != "" && asal.IDKota == tujuan.IDKota {
+		return ZonaDalamKota
+	}
+	return ZonaDalamProvinsi
+}
+
+// rateCardRules menggabungkan aturan dari SHIPPING_RATE_CARD dengan tarif dasar
+func rateCardRules() []RateCardRule {
+	raw := os.Getenv("SHIPPING_RATE_CARD")
+	if raw == "" {
+		return defaultRateCard
+	}
+
+	var custom []RateCardRule
+	if err := json.Unmarshal([]byte(raw), &custom); err != nil {
+		log.Printf("SHIPPING_RATE_CARD tidak valid, memakai tarif dasar: %v", err)
+		return defaultRateCard
+	}
+	return append(custom, defaultRateCard...)
+}
+
+// matchRateCardRule mengambil aturan pertama yang cocok dengan asal, tujuan dan zona
+func matchRateCardRule(rules []RateCardRule, asal, tujuan Lokasi, zona string) RateCardRule {
+	for _, rule := range rules {
+		if rule.AsalProvinsi != "" && rule.AsalProvinsi != asal.IDProvinsi {
+			continue
+		}
+		if rule.TujuanProvinsi != "" && rule.TujuanProvinsi != tujuan.IDProvinsi {
+			continue
+		}
+		if rule.Zona != "" && rule.Zona != zona {
+			continue
+		}
+		return rule
+	}
+	// Tarif dasar selalu memiliki aturan untuk setiap zona
+	return defaultRateCard[len(defaultRateCard)-1]
+}
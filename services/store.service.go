@@ -49,8 +49,9 @@ func GetStoreByID(id uint) (*models.Toko, error) {
 	return &store, err
 }
 
-// UpdateStore memperbarui informasi toko
-func UpdateStore(userID, storeID uint, updateData models.Toko) (*models.Toko, error) {
+// UpdateStore memperbarui informasi toko. digestStok bernilai nil jika pilihan ringkasan stok
+// harian tidak diubah.
+func UpdateStore(userID, storeID uint, updateData models.Toko, digestStok *bool) (*models.Toko, error) {
 	var store models.Toko
 
 	// Cek apakah toko ada dan milik user
@@ -64,6 +65,11 @@ func UpdateStore(userID, storeID uint, updateData models.Toko) (*models.Toko, er
 	if err != nil {
 		return nil, err
 	}
+	if digestStok != nil {
+		if err := config.DB.Model(&store).Update("digest_stok", *digestStok).Error; err != nil {
+			return nil, err
+		}
+	}
 
 	return &store, nil
 }
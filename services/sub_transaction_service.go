@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"math"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+)
+
+var ErrTokoTidakDitemukan = errors.New("toko tidak ditemukan")
+
+// GetStoreByUserID mengambil toko milik user
+func GetStoreByUserID(userID uint) (*models.Toko, error) {
+	var toko models.Toko
+	if err := config.DB.Where("id_user = ?", userID).First(&toko).Error; err != nil {
+		return nil, ErrTokoTidakDitemukan
+	}
+	return &toko, nil
+}
+
+// GetStoreSubTransactions mengambil sub-transaksi milik toko user dengan pagination
+func GetStoreSubTransactions(userID uint, status string, page, limit int) ([]models.SubTransaction, int64, int, error) {
+	toko, err := GetStoreByUserID(userID)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	query := config.DB.Model(&models.SubTransaction{}).Where("id_toko = ?", toko.ID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var subs []models.SubTransaction
+	offset := (page - 1) * limit
+	if err := query.Preload("DetailTransaksi.LogProduct").
+		Order("id DESC").Limit(limit).Offset(offset).Find(&subs).Error; err != nil {
+		return nil, 0, 0, errors.New("gagal mengambil sub-transaksi")
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	return subs, total, totalPages, nil
+}
+
+// GetStoreSubTransactionByID mengambil satu sub-transaksi milik toko user beserta alamat pengiriman
+func GetStoreSubTransactionByID(userID, subTrxID uint) (*models.SubTransaction, error) {
+	toko, err := GetStoreByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var sub models.SubTransaction
+	err = config.DB.Preload("DetailTransaksi.LogProduct").Preload("Transaction.Alamat").
+		Preload("TrackingEvents", func(db *gorm.DB) *gorm.DB {
+			return db.Order("waktu_kejadian ASC, id ASC")
+		}).
+		Where("id = ? AND id_toko = ?", subTrxID, toko.ID).First(&sub).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("sub-transaksi tidak ditemukan")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrAlasanBatalKosong     = errors.New("alasan pembatalan wajib diisi")
+	ErrTransaksiSudahDikirim = errors.New("transaksi yang sudah dikirim tidak dapat dibatalkan")
+)
+
+// restoreTransactionStock mengembalikan stok produk dari detail transaksi yang sub-transaksinya masih
+// berjalan. Harus dipanggil sebelum status transaksi diubah, karena sub-transaksi yang lebih dulu
+// dibatalkan penjualnya sudah mengembalikan stoknya sendiri.
+func restoreTransactionStock(tx *gorm.DB, trxID uint, change StockChange) error {
+	inactive := tx.Model(&models.SubTransaction{}).Select("id").
+		Where("id_trx = ? AND status IN ?", trxID, []string{models.TrxStatusCancelled, models.TrxStatusExpired})
+	return restoreDetailStock(tx, func(db *gorm.DB) *gorm.DB {
+		return db.Where("id_trx = ? AND id_sub_trx NOT IN (?)", trxID, inactive)
+	}, change)
+}
+
+// restoreDetailStock mengembalikan stok produk dari detail transaksi yang dipilih scope. Unit yang
+// sudah direfund tidak dikembalikan lagi karena sudah diproses (dan di-restock jika diminta) oleh refund.
+func restoreDetailStock(tx *gorm.DB, scope func(*gorm.DB) *gorm.DB, change StockChange) error {
+	var details []models.DetailTransaction
+	if err := tx.Scopes(scope).Preload("LogProduct").Find(&details).Error; err != nil {
+		return err
+	}
+
+	for _, detail := range details {
+		kuantitas := detail.Kuantitas - detail.KuantitasRefund
+		if kuantitas <= 0 {
+			continue
+		}
+		if err := ReleaseStock(tx, detail.LogProduct.IDProduk, detail.IDVarian, kuantitas, change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelSellerSubTransactions membatalkan sub-transaksi milik toko penjual saja. Stok dan pengajuan
+// refund sub-transaksi tersebut ikut diproses, lalu nilainya dikeluarkan dari total transaksi:
+// tagihan yang belum dibayar dibatalkan agar dibuat ulang dengan total baru, sedangkan transaksi
+// yang sudah dibayar mencatat nilainya sebagai refund ke pembeli. Nilai false dikembalikan jika
+// tidak ada sub-transaksi toko lain yang masih berjalan sehingga seluruh transaksi perlu dibatalkan.
+// Pemanggil harus sudah mengunci tagihan yang menunggu pembayaran sebelum mengunci transaksi.
+func cancelSellerSubTransactions(tx *gorm.DB, trx *models.Transaction, userID uint, alasan string) (bool, error) {
+	var toko models.Toko
+	if err := tx.Where("id_user = ?", userID).First(&toko).Error; err != nil {
+		return false, ErrAksesTransaksiDitolak
+	}
+
+	var subs []models.SubTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_trx = ?", trx.ID).
+		Order("id ASC").Find(&subs).Error; err != nil {
+		return false, err
+	}
+
+	var own []models.SubTransaction
+	otherActive := false
+	for _, sub := range subs {
+		if sub.Status == models.TrxStatusCancelled || sub.Status == models.TrxStatusExpired {
+			continue
+		}
+		if sub.IDToko == toko.ID {
+			own = append(own, sub)
+		} else {
+			otherActive = true
+		}
+	}
+	if len(own) == 0 {
+		return false, ErrTransisiTidakValid
+	}
+	if !otherActive {
+		return false, nil
+	}
+
+	for _, sub := range own {
+		if isShippedStatus(sub.Status) {
+			return false, ErrTransaksiSudahDikirim
+		}
+		allowed, ok := transactionTransitions[sub.Status][models.TrxStatusCancelled]
+		if !ok {
+			return false, ErrTransisiTidakValid
+		}
+		if _, ok := pickActor([]string{AktorSeller}, allowed); !ok {
+			return false, ErrAksesTransaksiDitolak
+		}
+	}
+
+	change := stockChangeForTransaction(trx, models.MovementCancel, AktorSeller, &userID, alasan)
+	for i := range own {
+		sub := &own[i]
+		subID := sub.ID
+		if err := restoreDetailStock(tx, func(db *gorm.DB) *gorm.DB {
+			return db.Where("id_sub_trx = ?", subID)
+		}, change); err != nil {
+			return false, err
+		}
+		if err := closeOpenRefunds(tx, trx.ID, &subID, AktorSeller, &userID, "Pesanan toko dibatalkan: "+alasan); err != nil {
+			return false, err
+		}
+		if err := applySubTransactionStatus(tx, sub, models.TrxStatusCancelled, AktorSeller, &userID,
+			TransitionInput{Catatan: alasan}); err != nil {
+			return false, err
+		}
+
+		nilai := sub.Subtotal + sub.OngkosKirim - sub.Diskon
+		if nilai < 0 {
+			nilai = 0
+		}
+		trxUpdates := map[string]interface{}{
+			"harga_total": gorm.Expr("harga_total - ?", nilai),
+			"diskon":      gorm.Expr("diskon - ?", sub.Diskon),
+		}
+		if trx.Status != models.TrxStatusPendingPayment {
+			trxUpdates["total_refund"] = gorm.Expr("total_refund + ?", nilai)
+			if err := tx.Model(&models.SubTransaction{}).Where("id = ?", sub.ID).
+				Update("total_refund", gorm.Expr("total_refund + ?", nilai)).Error; err != nil {
+				return false, err
+			}
+		}
+		if err := tx.Model(&models.Transaction{}).Where("id = ?", trx.ID).Updates(trxUpdates).Error; err != nil {
+			return false, err
+		}
+	}
+
+	if trx.Status == models.TrxStatusPendingPayment {
+		if err := tx.Model(&models.Payment{}).
+			Where("id_trx = ? AND status = ?", trx.ID, models.PaymentStatusPending).
+			Update("status", models.PaymentStatusExpired).Error; err != nil {
+			return false, err
+		}
+	}
+
+	if err := syncTransactionStatus(tx, trx, AktorSeller, &userID, alasan); err != nil {
+		return false, err
+	}
+	return true, tx.First(trx, trx.ID).Error
+}
+
+// recordCancelRefund mencatat sisa nilai transaksi yang sudah dibayar sebagai refund ke pembeli saat
+// seluruh transaksi dibatalkan. harga_total sudah dikurangi refund yang disetujui dan sub-transaksi
+// yang dibatalkan penjual, sehingga yang dicatat hanya nilai yang belum dikembalikan. Setiap
+// sub-transaksi yang masih berjalan mencatat bagiannya, sama seperti cancelSellerSubTransactions.
+func recordCancelRefund(tx *gorm.DB, trx *models.Transaction) error {
+	var subs []models.SubTransaction
+	if err := tx.Where("id_trx = ? AND status NOT IN ?", trx.ID,
+		[]string{models.TrxStatusCancelled, models.TrxStatusExpired}).Find(&subs).Error; err != nil {
+		return err
+	}
+	for _, sub := range subs {
+		nilai := sub.Subtotal + sub.OngkosKirim - sub.Diskon
+		if nilai <= 0 {
+			continue
+		}
+		if err := tx.Model(&models.SubTransaction{}).Where("id = ?", sub.ID).
+			Update("total_refund", gorm.Expr("total_refund + ?", nilai)).Error; err != nil {
+			return err
+		}
+	}
+
+	if trx.HargaTotal <= 0 {
+		return nil
+	}
+	if err := tx.Model(&models.Transaction{}).Where("id = ?", trx.ID).
+		Update("total_refund", gorm.Expr("total_refund + ?", trx.HargaTotal)).Error; err != nil {
+		return err
+	}
+	trx.TotalRefund += trx.HargaTotal
+	return nil
+}
+
+// isShippedStatus memeriksa apakah pesanan sudah diserahkan ke kurir
+func isShippedStatus(status string) bool {
+	switch status {
+	case models.TrxStatusShipped, models.TrxStatusDelivered, models.TrxStatusCompleted:
+		return true
+	}
+	return false
+}
+
+// CancelTransaction membatalkan transaksi atas nama buyer/seller/admin dan mengembalikan stok
+// produk dalam satu DB transaction. Pengajuan refund yang belum diputuskan ikut ditolak. Penjual
+// hanya membatalkan sub-transaksi tokonya; transaksi ikut dibatalkan jika tidak ada lagi
+// sub-transaksi toko lain yang berjalan.
+func CancelTransaction(trxID, userID uint, alasan string) (*models.Transaction, error) {
+	alasan = strings.TrimSpace(alasan)
+	if alasan == "" {
+		return nil, ErrAlasanBatalKosong
+	}
+
+	var trx *models.Transaction
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		// Tagihan dikunci sebelum transaksi, urutan yang sama dengan webhook pembayaran dan
+		// scheduler kadaluarsa, karena pembatalan ikut menutup tagihan yang masih menunggu
+		if err := lockPendingPayments(tx, trxID); err != nil {
+			return err
+		}
+
+		var err error
+		trx, err = LockTransaction(tx, trxID)
+		if err != nil {
+			return err
+		}
+
+		userActors, err := resolveTransactionActors(tx, trx, userID)
+		if err != nil {
+			return err
+		}
+		if len(userActors) == 0 {
+			return ErrTransaksiTidakDitemukan
+		}
+
+		if isShippedStatus(trx.Status) {
+			return ErrTransaksiSudahDikirim
+		}
+		allowed, ok := transactionTransitions[trx.Status][models.TrxStatusCancelled]
+		if !ok {
+			return ErrTransisiTidakValid
+		}
+		aktor, ok := pickActor(userActors, allowed)
+		if !ok {
+			return ErrAksesTransaksiDitolak
+		}
+
+		if aktor == AktorSeller {
+			var subCount int64
+			if err := tx.Model(&models.SubTransaction{}).Where("id_trx = ?", trx.ID).Count(&subCount).Error; err != nil {
+				return err
+			}
+			if subCount > 0 {
+				partial, err := cancelSellerSubTransactions(tx, trx, userID, alasan)
+				if err != nil || partial {
+					return err
+				}
+			}
+		}
+
+		// Tolak pembatalan jika salah satu sub-transaksi sudah dikirim
+		var shipped int64
+		tx.Model(&models.SubTransaction{}).Where("id_trx = ? AND status IN ?", trx.ID,
+			[]string{models.TrxStatusShipped, models.TrxStatusDelivered, models.TrxStatusCompleted}).Count(&shipped)
+		if shipped > 0 {
+			return ErrTransaksiSudahDikirim
+		}
+
+		// Stok dikembalikan sebelum status berubah agar sub-transaksi yang sudah dibatalkan dilewati
+		if err := restoreTransactionStock(tx, trx.ID,
+			stockChangeForTransaction(trx, models.MovementCancel, aktor, &userID, alasan)); err != nil {
+			return err
+		}
+
+		// Nilai pesanan yang sudah dibayar dicatat sebagai refund sebelum sub-transaksi ikut dibatalkan
+		if trx.Status != models.TrxStatusPendingPayment {
+			if err := recordCancelRefund(tx, trx); err != nil {
+				return err
+			}
+		}
+
+		if err := ApplyTransactionStatus(tx, trx, models.TrxStatusCancelled, aktor, &userID, alasan); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&models.Transaction{}).Where("id = ?", trx.ID).Updates(map[string]interface{}{
+			"alasan_batal":    alasan,
+			"dibatalkan_pada": now,
+		}).Error; err != nil {
+			return err
+		}
+		trx.AlasanBatal = alasan
+		trx.DibatalkanPada = &now
+
+		// Tagihan yang belum dibayar ditutup agar tidak bisa dilunasi setelah transaksi dibatalkan
+		if err := tx.Model(&models.Payment{}).
+			Where("id_trx = ? AND status = ?", trx.ID, models.PaymentStatusPending).
+			Update("status", models.PaymentStatusExpired).Error; err != nil {
+			return err
+		}
+
+		if err := releaseVoucherUsage(tx, trx.ID); err != nil {
+			return err
+		}
+		return closeOpenRefunds(tx, trx.ID, nil, aktor, &userID, "Transaksi dibatalkan: "+alasan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return trx, nil
+}
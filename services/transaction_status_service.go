@@ -0,0 +1,387 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Peran pihak yang memindahkan status transaksi
+const (
+	AktorBuyer  = "buyer"
+	AktorSeller = "seller"
+	AktorAdmin  = "admin"
+	AktorSystem = "system"
+)
+
+var (
+	ErrTransaksiTidakDitemukan = errors.New("transaksi tidak ditemukan")
+	ErrTransisiTidakValid      = errors.New("perubahan status transaksi tidak diizinkan")
+	ErrAksesTransaksiDitolak   = errors.New("anda tidak memiliki akses untuk mengubah status transaksi ini")
+	ErrNoResiKosong            = errors.New("nomor resi wajib diisi saat pengiriman")
+)
+
+// TransitionInput berisi data tambahan yang menyertai perpindahan status
+type TransitionInput struct {
+	Catatan string
+	NoResi  string
+	Kurir   string
+}
+
+// transactionTransitions berisi tabel transisi status: status asal -> status tujuan -> aktor yang diizinkan
+var transactionTransitions = map[string]map[string][]string{
+	models.TrxStatusPendingPayment: {
+		models.TrxStatusPaid:      {AktorAdmin, AktorSystem},
+		models.TrxStatusCancelled: {AktorBuyer, AktorSeller, AktorAdmin},
+		models.TrxStatusExpired:   {AktorAdmin, AktorSystem},
+	},
+	models.TrxStatusPaid: {
+		models.TrxStatusProcessing: {AktorSeller, AktorAdmin},
+		models.TrxStatusCancelled:  {AktorBuyer, AktorSeller, AktorAdmin},
+	},
+	models.TrxStatusProcessing: {
+		models.TrxStatusShipped:   {AktorSeller, AktorAdmin},
+		models.TrxStatusCancelled: {AktorSeller, AktorAdmin},
+	},
+	models.TrxStatusShipped: {
+		models.TrxStatusDelivered: {AktorSeller, AktorAdmin, AktorSystem},
+	},
+	models.TrxStatusDelivered: {
+		models.TrxStatusCompleted: {AktorBuyer, AktorAdmin, AktorSystem},
+	},
+}
+
+// Status fulfilment dijalankan per toko melalui sub-transaksi, status lainnya berlaku untuk seluruh transaksi
+var fulfilmentStatuses = map[string]bool{
+	models.TrxStatusProcessing: true,
+	models.TrxStatusShipped:    true,
+	models.TrxStatusDelivered:  true,
+}
+
+// Urutan status fulfilment untuk menurunkan status transaksi dari sub-transaksinya
+var fulfilmentOrder = []string{
+	models.TrxStatusPaid,
+	models.TrxStatusProcessing,
+	models.TrxStatusShipped,
+	models.TrxStatusDelivered,
+}
+
+func fulfilmentRank(status string) int {
+	for i, s := range fulfilmentOrder {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// CanTransition mengecek apakah status boleh berpindah dari `from` ke `to`
+func CanTransition(from, to string) bool {
+	_, ok := transactionTransitions[from][to]
+	return ok
+}
+
+// resolveTransactionActors menentukan peran user terhadap sebuah transaksi
+func resolveTransactionActors(db *gorm.DB, trx *models.Transaction, userID uint) ([]string, error) {
+	actor, err := resolveActor(db, userID)
+	if err != nil {
+		return nil, err
+	}
+	return actor.TransactionRoles(db, trx), nil
+}
+
+// pickActor memilih peran pertama user yang diizinkan untuk transisi
+func pickActor(userActors, allowed []string) (string, bool) {
+	for _, a := range userActors {
+		for _, b := range allowed {
+			if a == b {
+				return a, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ApplyTransactionStatus memindahkan status transaksi di dalam DB transaction yang sedang berjalan
+// dan mencatatnya ke riwayat status. Transaksi harus sudah dikunci oleh pemanggil.
+func ApplyTransactionStatus(tx *gorm.DB, trx *models.Transaction, to, aktor string, userID *uint, catatan string) error {
+	allowed, ok := transactionTransitions[trx.Status][to]
+	if !ok {
+		return ErrTransisiTidakValid
+	}
+	if _, ok := pickActor([]string{aktor}, allowed); !ok {
+		return ErrAksesTransaksiDitolak
+	}
+
+	from := trx.Status
+	result := tx.Model(&models.Transaction{}).
+		Where("id = ? AND status = ?", trx.ID, from).
+		Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTransisiTidakValid
+	}
+
+	history := models.TransactionStatusHistory{
+		IDTrx:      trx.ID,
+		StatusDari: from,
+		StatusKe:   to,
+		IDUser:     userID,
+		Aktor:      aktor,
+		Catatan:    catatan,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	trx.Status = to
+
+	// Status tingkat transaksi (bayar, batal, kadaluarsa, selesai) diturunkan ke semua sub-transaksi
+	if !fulfilmentStatuses[to] {
+		return cascadeSubTransactionStatus(tx, trx.ID, to, aktor, userID, catatan)
+	}
+	return nil
+}
+
+// applySubTransactionStatus memindahkan status satu sub-transaksi dan mencatatnya ke riwayat status
+func applySubTransactionStatus(tx *gorm.DB, sub *models.SubTransaction, to, aktor string, userID *uint, input TransitionInput) error {
+	from := sub.Status
+	now := time.Now()
+	updates := map[string]interface{}{"status": to}
+	if to == models.TrxStatusShipped {
+		updates["no_resi"] = input.NoResi
+		updates["kurir"] = input.Kurir
+		updates["dikirim_pada"] = now
+	}
+
+	result := tx.Model(&models.SubTransaction{}).
+		Where("id = ? AND status = ?", sub.ID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTransisiTidakValid
+	}
+
+	history := models.TransactionStatusHistory{
+		IDTrx:      sub.IDTrx,
+		IDSubTrx:   &sub.ID,
+		StatusDari: from,
+		StatusKe:   to,
+		IDUser:     userID,
+		Aktor:      aktor,
+		Catatan:    input.Catatan,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	sub.Status = to
+	if to == models.TrxStatusShipped {
+		sub.NoResi = input.NoResi
+		sub.Kurir = input.Kurir
+		sub.DikirimPada = &now
+		return recordShipmentEvent(tx, sub, now)
+	}
+	return nil
+}
+
+// cascadeSubTransactionStatus menerapkan status transaksi ke sub-transaksi yang bisa berpindah ke status tersebut
+func cascadeSubTransactionStatus(tx *gorm.DB, trxID uint, to, aktor string, userID *uint, catatan string) error {
+	var subs []models.SubTransaction
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_trx = ?", trxID).Find(&subs).Error; err != nil {
+		return err
+	}
+
+	for i := range subs {
+		if !CanTransition(subs[i].Status, to) {
+			continue
+		}
+		if err := applySubTransactionStatus(tx, &subs[i], to, aktor, userID, TransitionInput{Catatan: catatan}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncTransactionStatus menaikkan status transaksi mengikuti sub-transaksi yang paling tertinggal
+func syncTransactionStatus(tx *gorm.DB, trx *models.Transaction, aktor string, userID *uint, catatan string) error {
+	var subs []models.SubTransaction
+	if err := tx.Where("id_trx = ? AND status NOT IN ?", trx.ID,
+		[]string{models.TrxStatusCancelled, models.TrxStatusExpired}).Find(&subs).Error; err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+
+	target := len(fulfilmentOrder) - 1
+	for _, sub := range subs {
+		if rank := fulfilmentRank(sub.Status); rank >= 0 && rank < target {
+			target = rank
+		}
+	}
+
+	for rank := fulfilmentRank(trx.Status); rank >= 0 && rank < target; rank++ {
+		if err := ApplyTransactionStatus(tx, trx, fulfilmentOrder[rank+1], aktor, userID, catatan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transitionSubTransactions memindahkan status sub-transaksi milik toko user (atau semua sub-transaksi
+// untuk admin), lalu menyesuaikan status transaksi induk
+func transitionSubTransactions(tx *gorm.DB, trx *models.Transaction, userID uint, userActors []string, to string, input TransitionInput) error {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id_trx = ?", trx.ID)
+
+	var aktor string
+	if _, ok := pickActor(userActors, []string{AktorSeller}); ok {
+		var toko models.Toko
+		if err := tx.Where("id_user = ?", userID).First(&toko).Error; err != nil {
+			return ErrAksesTransaksiDitolak
+		}
+		query = query.Where("id_toko = ?", toko.ID)
+		aktor = AktorSeller
+	} else if _, ok := pickActor(userActors, []string{AktorAdmin}); ok {
+		aktor = AktorAdmin
+	} else {
+		return ErrAksesTransaksiDitolak
+	}
+
+	var subs []models.SubTransaction
+	if err := query.Find(&subs).Error; err != nil {
+		return err
+	}
+
+	moved := 0
+	for i := range subs {
+		allowed, ok := transactionTransitions[subs[i].Status][to]
+		if !ok {
+			continue
+		}
+		if _, ok := pickActor([]string{aktor}, allowed); !ok {
+			return ErrAksesTransaksiDitolak
+		}
+		if err := applySubTransactionStatus(tx, &subs[i], to, aktor, &userID, input); err != nil {
+			return err
+		}
+		moved++
+	}
+	if moved == 0 {
+		return ErrTransisiTidakValid
+	}
+
+	return syncTransactionStatus(tx, trx, aktor, &userID, input.Catatan)
+}
+
+// RecordInitialStatus mencatat status awal transaksi yang baru dibuat
+func RecordInitialStatus(tx *gorm.DB, trx *models.Transaction, userID uint) error {
+	history := models.TransactionStatusHistory{
+		IDTrx:    trx.ID,
+		StatusKe: trx.Status,
+		IDUser:   &userID,
+		Aktor:    AktorBuyer,
+	}
+	return tx.Create(&history).Error
+}
+
+// LockTransaction mengambil transaksi dengan row lock di dalam DB transaction
+func LockTransaction(tx *gorm.DB, trxID uint) (*models.Transaction, error) {
+	var trx models.Transaction
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&trx, trxID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &trx, nil
+}
+
+// TransitionTransaction memindahkan status transaksi atas nama user. Status fulfilment
+// (diproses, dikirim, diterima) diterapkan ke sub-transaksi milik toko user.
+func TransitionTransaction(trxID, userID uint, to string, input TransitionInput) (*models.Transaction, error) {
+	input.NoResi = strings.TrimSpace(input.NoResi)
+	if to == models.TrxStatusShipped {
+		if input.NoResi == "" {
+			return nil, ErrNoResiKosong
+		}
+		kurir, err := normalizeCourier(input.Kurir)
+		if err != nil {
+			return nil, err
+		}
+		input.Kurir = kurir
+	}
+
+	var trx *models.Transaction
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		trx, err = LockTransaction(tx, trxID)
+		if err != nil {
+			return err
+		}
+
+		userActors, err := resolveTransactionActors(tx, trx, userID)
+		if err != nil {
+			return err
+		}
+		if len(userActors) == 0 {
+			return ErrTransaksiTidakDitemukan
+		}
+
+		// Transaksi lama yang belum memiliki sub-transaksi dipindahkan secara langsung
+		var subCount int64
+		tx.Model(&models.SubTransaction{}).Where("id_trx = ?", trx.ID).Count(&subCount)
+		if fulfilmentStatuses[to] && subCount > 0 {
+			return transitionSubTransactions(tx, trx, userID, userActors, to, input)
+		}
+
+		allowed, ok := transactionTransitions[trx.Status][to]
+		if !ok {
+			return ErrTransisiTidakValid
+		}
+		aktor, ok := pickActor(userActors, allowed)
+		if !ok {
+			return ErrAksesTransaksiDitolak
+		}
+
+		return ApplyTransactionStatus(tx, trx, to, aktor, &userID, input.Catatan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return trx, nil
+}
+
+// GetTransactionStatusHistory mengambil riwayat status sebuah transaksi yang melibatkan user
+func GetTransactionStatusHistory(trxID, userID uint) ([]models.TransactionStatusHistory, error) {
+	var trx models.Transaction
+	if err := config.DB.First(&trx, trxID).Error; err != nil {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+
+	userActors, err := resolveTransactionActors(config.DB, &trx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(userActors) == 0 {
+		return nil, ErrTransaksiTidakDitemukan
+	}
+
+	var history []models.TransactionStatusHistory
+	if err := config.DB.Where("id_trx = ?", trxID).Order("id ASC").Find(&history).Error; err != nil {
+		return nil, errors.New("gagal mengambil riwayat status transaksi")
+	}
+	return history, nil
+}
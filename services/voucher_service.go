@@ -0,0 +1,435 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/habbazettt/evermos-service-go/config"
+	"github.com/habbazettt/evermos-service-go/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrVoucherTidakDitemukan    = errors.New("voucher tidak ditemukan")
+	ErrVoucherTidakBerlaku      = errors.New("voucher tidak aktif atau di luar masa berlaku")
+	ErrVoucherMinBelanja        = errors.New("total belanja belum memenuhi minimum pembelian voucher")
+	ErrVoucherProdukTidakSesuai = errors.New("voucher tidak berlaku untuk produk yang dibeli")
+	ErrKuotaVoucherHabis        = errors.New("kuota voucher sudah habis")
+	ErrKuotaVoucherUserHabis    = errors.New("batas pemakaian voucher untuk akun ini sudah tercapai")
+	ErrDataVoucherTidakValid    = errors.New("data voucher tidak valid")
+	ErrAksesVoucherDitolak      = errors.New("tidak memiliki akses ke voucher ini")
+)
+
+// VoucherInput adalah data untuk membuat atau mengubah voucher
+type VoucherInput struct {
+	Kode         string     `json:"kode"`
+	Nama         string     `json:"nama"`
+	Tipe         string     `json:"tipe"`
+	Nilai        int        `json:"nilai"`
+	MaksDiskon   int        `json:"maks_diskon"`
+	MinBelanja   int        `json:"min_belanja"`
+	KuotaTotal   int        `json:"kuota_total"`
+	KuotaPerUser int        `json:"kuota_per_user"`
+	MulaiPada    *time.Time `json:"mulai_pada"`
+	BerakhirPada *time.Time `json:"berakhir_pada"`
+	IDToko       *uint      `json:"id_toko"` // hanya untuk admin, voucher penjual selalu terikat ke tokonya
+	IDCategories []uint     `json:"id_categories"`
+	Aktif        *bool      `json:"aktif"`
+}
+
+// VoucherDiscount adalah hasil perhitungan potongan voucher beserta pembagiannya per toko
+type VoucherDiscount struct {
+	Voucher *models.Voucher
+	Total   int
+	PerToko map[uint]int
+}
+
+// ForToko mengembalikan potongan untuk satu toko, aman dipanggil pada nil
+func (d *VoucherDiscount) ForToko(tokoID uint) int {
+	if d == nil {
+		return 0
+	}
+	return d.PerToko[tokoID]
+}
+
+// ForLines membagi potongan satu toko ke baris checkout toko tersebut yang memenuhi syarat voucher,
+// sebanding dengan nilai barisnya. Sisa pembulatan diberikan ke baris terakhir yang memenuhi syarat
+// agar jumlahnya sama dengan potongan toko. Aman dipanggil pada nil.
+func (d *VoucherDiscount) ForLines(tokoID uint, lines []checkoutLine) []int {
+	shares := make([]int, len(lines))
+	total := d.ForToko(tokoID)
+	if total == 0 {
+		return shares
+	}
+
+	eligibleTotal := 0
+	last := -1
+	for i := range lines {
+		if voucherCoversLine(d.Voucher, &lines[i]) {
+			eligibleTotal += lines[i].HargaTotal
+			last = i
+		}
+	}
+	if eligibleTotal == 0 {
+		return shares
+	}
+
+	remaining := total
+	for i := range lines {
+		if !voucherCoversLine(d.Voucher, &lines[i]) {
+			continue
+		}
+		share := total * lines[i].HargaTotal / eligibleTotal
+		if i == last {
+			share = remaining
+		}
+		shares[i] = share
+		remaining -= share
+	}
+	return shares
+}
+
+// voucherCoversLine mengecek apakah baris checkout memenuhi syarat toko dan kategori voucher
+func voucherCoversLine(voucher *models.Voucher, line *checkoutLine) bool {
+	if voucher.IDToko != nil && line.Produk.IDToko != *voucher.IDToko {
+		return false
+	}
+	if len(voucher.Categories) == 0 {
+		return true
+	}
+	for _, category := range voucher.Categories {
+		if category.ID == line.Produk.IDCategory {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeVoucherCode menyeragamkan penulisan kode voucher
+func normalizeVoucherCode(kode string) string {
+	return strings.ToUpper(strings.TrimSpace(kode))
+}
+
+// checkVoucherActive memastikan voucher aktif dan berada di dalam masa berlaku
+func checkVoucherActive(voucher *models.Voucher, now time.Time) error {
+	if !voucher.Aktif {
+		return ErrVoucherTidakBerlaku
+	}
+	if voucher.MulaiPada != nil && now.Before(*voucher.MulaiPada) {
+		return ErrVoucherTidakBerlaku
+	}
+	if voucher.BerakhirPada != nil && now.After(*voucher.BerakhirPada) {
+		return ErrVoucherTidakBerlaku
+	}
+	return nil
+}
+
+// checkVoucherUserQuota memastikan user belum melewati batas pemakaian voucher
+func checkVoucherUserQuota(db *gorm.DB, voucher *models.Voucher, userID uint) error {
+	if voucher.KuotaPerUser == 0 {
+		return nil
+	}
+	var used int64
+	db.Model(&models.VoucherUsage{}).Where("id_voucher = ? AND id_user = ?", voucher.ID, userID).Count(&used)
+	if int(used) >= voucher.KuotaPerUser {
+		return ErrKuotaVoucherUserHabis
+	}
+	return nil
+}
+
+// calculateVoucherDiscount menghitung potongan voucher dari baris checkout yang memenuhi syarat
+// toko dan kategori, lalu membaginya ke setiap toko sebanding dengan nilai belanjanya
+func calculateVoucherDiscount(voucher *models.Voucher, lines []checkoutLine) (*VoucherDiscount, error) {
+	eligible := map[uint]int{}
+	var tokoIDs []uint
+	eligibleTotal := 0
+	for i := range lines {
+		line := &lines[i]
+		if !voucherCoversLine(voucher, line) {
+			continue
+		}
+		if _, ok := eligible[line.Produk.IDToko]; !ok {
+			tokoIDs = append(tokoIDs, line.Produk.IDToko)
+		}
+		eligible[line.Produk.IDToko] += line.HargaTotal
+		eligibleTotal += line.HargaTotal
+	}
+
+	if eligibleTotal == 0 {
+		return nil, ErrVoucherProdukTidakSesuai
+	}
+	if eligibleTotal < voucher.MinBelanja {
+		return nil, ErrVoucherMinBelanja
+	}
+
+	total := voucher.Nilai
+	if voucher.Tipe == models.VoucherTipePersen {
+		total = eligibleTotal * voucher.Nilai / 100
+		if voucher.MaksDiskon > 0 && total > voucher.MaksDiskon {
+			total = voucher.MaksDiskon
+		}
+	}
+	if total > eligibleTotal {
+		total = eligibleTotal
+	}
+
+	// Sisa pembulatan diberikan ke toko terakhir agar jumlah per toko sama dengan total
+	sort.Slice(tokoIDs, func(i, j int) bool { return tokoIDs[i] < tokoIDs[j] })
+	discount := &VoucherDiscount{Voucher: voucher, Total: total, PerToko: map[uint]int{}}
+	remaining := total
+	for i, tokoID := range tokoIDs {
+		share := total * eligible[tokoID] / eligibleTotal
+		if i == len(tokoIDs)-1 {
+			share = remaining
+		}
+		discount.PerToko[tokoID] = share
+		remaining -= share
+	}
+	return discount, nil
+}
+
+// EvaluateVoucher menghitung potongan voucher tanpa memakai kuotanya, dipakai oleh quote
+func EvaluateVoucher(db *gorm.DB, userID uint, kode string, lines []checkoutLine) (*VoucherDiscount, error) {
+	var voucher models.Voucher
+	if err := db.Preload("Categories").Where("kode = ?", normalizeVoucherCode(kode)).First(&voucher).Error; err != nil {
+		return nil, ErrVoucherTidakDitemukan
+	}
+	if err := checkVoucherActive(&voucher, time.Now()); err != nil {
+		return nil, err
+	}
+	if voucher.KuotaTotal > 0 && voucher.Terpakai >= voucher.KuotaTotal {
+		return nil, ErrKuotaVoucherHabis
+	}
+	if err := checkVoucherUserQuota(db, &voucher, userID); err != nil {
+		return nil, err
+	}
+	return calculateVoucherDiscount(&voucher, lines)
+}
+
+// redeemVoucher memakai voucher untuk transaksi di dalam DB transaction checkout. Row voucher
+// dikunci agar batas per user tidak terlewati oleh checkout paralel, dan kuota total ditambah
+// dengan `UPDATE ... WHERE terpakai < kuota_total`.
+func redeemVoucher(tx *gorm.DB, userID, trxID uint, kode string, lines []checkoutLine) (*VoucherDiscount, error) {
+	var voucher models.Voucher
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("kode = ?", normalizeVoucherCode(kode)).First(&voucher).Error; err != nil {
+		return nil, ErrVoucherTidakDitemukan
+	}
+	if err := tx.Model(&voucher).Association("Categories").Find(&voucher.Categories); err != nil {
+		return nil, err
+	}
+
+	if err := checkVoucherActive(&voucher, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := checkVoucherUserQuota(tx, &voucher, userID); err != nil {
+		return nil, err
+	}
+
+	discount, err := calculateVoucherDiscount(&voucher, lines)
+	if err != nil {
+		return nil, err
+	}
+
+	result := tx.Model(&models.Voucher{}).
+		Where("id = ? AND (kuota_total = 0 OR terpakai < kuota_total)", voucher.ID).
+		Update("terpakai", gorm.Expr("terpakai + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrKuotaVoucherHabis
+	}
+
+	usage := models.VoucherUsage{
+		IDVoucher: voucher.ID,
+		IDUser:    userID,
+		IDTrx:     trxID,
+		Diskon:    discount.Total,
+	}
+	if err := tx.Create(&usage).Error; err != nil {
+		return nil, errors.New("gagal menyimpan pemakaian voucher")
+	}
+
+	return discount, nil
+}
+
+// releaseVoucherUsage mengembalikan kuota voucher saat transaksi dibatalkan atau kadaluarsa
+func releaseVoucherUsage(tx *gorm.DB, trxID uint) error {
+	var usage models.VoucherUsage
+	if err := tx.Where("id_trx = ?", trxID).First(&usage).Error; err != nil {
+		return nil
+	}
+
+	if err := tx.Delete(&usage).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Voucher{}).
+		Where("id = ? AND terpakai > 0", usage.IDVoucher).
+		Update("terpakai", gorm.Expr("terpakai - 1")).Error
+}
+
+// validateVoucherInput memeriksa kelengkapan data voucher
+func validateVoucherInput(input *VoucherInput) error {
+	input.Kode = normalizeVoucherCode(input.Kode)
+	input.Nama = strings.TrimSpace(input.Nama)
+	if input.Kode == "" || input.Nilai <= 0 {
+		return ErrDataVoucherTidakValid
+	}
+	if input.Tipe != models.VoucherTipePersen && input.Tipe != models.VoucherTipeNominal {
+		return ErrDataVoucherTidakValid
+	}
+	if input.Tipe == models.VoucherTipePersen && input.Nilai > 100 {
+		return ErrDataVoucherTidakValid
+	}
+	if input.MaksDiskon < 0 || input.MinBelanja < 0 || input.KuotaTotal < 0 || input.KuotaPerUser < 0 {
+		return ErrDataVoucherTidakValid
+	}
+	if input.MulaiPada != nil && input.BerakhirPada != nil && input.BerakhirPada.Before(*input.MulaiPada) {
+		return ErrDataVoucherTidakValid
+	}
+	return nil
+}
+
+// applyVoucherInput menyalin data input ke voucher sesuai hak akses actor
+func applyVoucherInput(db *gorm.DB, actor *Actor, voucher *models.Voucher, input *VoucherInput) error {
+	voucher.Kode = input.Kode
+	voucher.Nama = input.Nama
+	voucher.Tipe = input.Tipe
+	voucher.Nilai = input.Nilai
+	voucher.MaksDiskon = input.MaksDiskon
+	voucher.MinBelanja = input.MinBelanja
+	voucher.KuotaTotal = input.KuotaTotal
+	voucher.KuotaPerUser = input.KuotaPerUser
+	voucher.MulaiPada = input.MulaiPada
+	voucher.BerakhirPada = input.BerakhirPada
+	if input.Aktif != nil {
+		voucher.Aktif = *input.Aktif
+	}
+
+	// Penjual hanya dapat membuat voucher untuk tokonya sendiri
+	if actor.IsAdmin {
+		voucher.IDToko = input.IDToko
+	} else {
+		tokoID := actor.TokoID
+		voucher.IDToko = &tokoID
+	}
+
+	voucher.Categories = []models.Category{}
+	if len(input.IDCategories) > 0 {
+		if err := db.Where("id IN ?", input.IDCategories).Find(&voucher.Categories).Error; err != nil {
+			return err
+		}
+		if len(voucher.Categories) != len(input.IDCategories) {
+			return errors.New("kategori tidak ditemukan")
+		}
+	}
+	return nil
+}
+
+// GetVouchers mengambil voucher yang dapat dikelola user: semua voucher untuk admin,
+// voucher toko sendiri untuk penjual
+func GetVouchers(userID uint) ([]models.Voucher, error) {
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := config.DB.Preload("Categories")
+	if !actor.IsAdmin {
+		if actor.TokoID == 0 {
+			return nil, ErrAksesVoucherDitolak
+		}
+		query = query.Where("id_toko = ?", actor.TokoID)
+	}
+
+	vouchers := []models.Voucher{}
+	if err := query.Order("id DESC").Find(&vouchers).Error; err != nil {
+		return nil, errors.New("gagal mengambil voucher")
+	}
+	return vouchers, nil
+}
+
+// CreateVoucher membuat voucher baru oleh admin atau penjual
+func CreateVoucher(userID uint, input VoucherInput) (*models.Voucher, error) {
+	if err := validateVoucherInput(&input); err != nil {
+		return nil, err
+	}
+
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.IsAdmin && actor.TokoID == 0 {
+		return nil, ErrAksesVoucherDitolak
+	}
+
+	voucher := models.Voucher{Aktif: true}
+	if err := applyVoucherInput(config.DB, actor, &voucher, &input); err != nil {
+		return nil, err
+	}
+	if err := config.DB.Create(&voucher).Error; err != nil {
+		return nil, errors.New("gagal menyimpan voucher, pastikan kode voucher belum dipakai")
+	}
+	return &voucher, nil
+}
+
+// UpdateVoucher mengubah voucher milik toko user atau voucher apa pun untuk admin
+func UpdateVoucher(userID, voucherID uint, input VoucherInput) (*models.Voucher, error) {
+	if err := validateVoucherInput(&input); err != nil {
+		return nil, err
+	}
+
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var voucher models.Voucher
+	if err := config.DB.First(&voucher, voucherID).Error; err != nil {
+		return nil, ErrVoucherTidakDitemukan
+	}
+	if !actor.CanManageVoucher(&voucher) {
+		return nil, ErrAksesVoucherDitolak
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applyVoucherInput(tx, actor, &voucher, &input); err != nil {
+			return err
+		}
+		if err := tx.Omit("Categories", "terpakai").Save(&voucher).Error; err != nil {
+			return errors.New("gagal memperbarui voucher")
+		}
+		return tx.Model(&voucher).Association("Categories").Replace(voucher.Categories)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &voucher, nil
+}
+
+// DeleteVoucher menghapus voucher milik toko user atau voucher apa pun untuk admin
+func DeleteVoucher(userID, voucherID uint) error {
+	actor, err := ResolveActor(userID)
+	if err != nil {
+		return err
+	}
+
+	var voucher models.Voucher
+	if err := config.DB.First(&voucher, voucherID).Error; err != nil {
+		return ErrVoucherTidakDitemukan
+	}
+	if !actor.CanManageVoucher(&voucher) {
+		return ErrAksesVoucherDitolak
+	}
+
+	return config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&voucher).Association("Categories").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&voucher).Error
+	})
+}
@@ -0,0 +1,293 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Pembaca dan penulis XLSX minimal: hanya sheet pertama, nilai sel sebagai teks, tanpa format.
+// Cukup untuk impor dan ekspor tabel produk tanpa menambah dependensi.
+
+const (
+	// xlsxMaxRows membatasi nomor baris agar file rusak atau berbahaya tidak menghabiskan memori
+	xlsxMaxRows = 100000
+	// xlsxMaxPartSize membatasi ukuran satu bagian XML setelah didekompresi
+	xlsxMaxPartSize = 64 << 20
+)
+
+var ErrXLSXTidakValid = errors.New("file xlsx tidak valid")
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		R     int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string    `xml:"r,attr"`
+			Type   string    `xml:"t,attr"`
+			Value  string    `xml:"v"`
+			Inline *xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX membaca sheet pertama file XLSX menjadi baris-baris sel teks. Nomor baris pada
+// spreadsheet dipertahankan: baris kosong di antara data dikembalikan sebagai slice kosong.
+func ReadXLSX(r io.ReaderAt, size int64) ([][]string, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, ErrXLSXTidakValid
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook xlsxWorkbook
+	if err := decodeXLSXPart(files, "xl/workbook.xml", &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return nil, ErrXLSXTidakValid
+	}
+	var rels xlsxRelationships
+	if err := decodeXLSXPart(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, ErrXLSXTidakValid
+	}
+	sheetPath := ""
+	for _, rel := range rels.Items {
+		if rel.ID == workbook.Sheets[0].RID {
+			sheetPath = rel.Target
+		}
+	}
+	if sheetPath == "" {
+		return nil, ErrXLSXTidakValid
+	}
+	if strings.HasPrefix(sheetPath, "/") {
+		sheetPath = strings.TrimPrefix(sheetPath, "/")
+	} else {
+		sheetPath = path.Join("xl", sheetPath)
+	}
+
+	// sharedStrings tidak wajib ada jika semua teks disimpan inline
+	var shared xlsxSharedStrings
+	if _, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeXLSXPart(files, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, ErrXLSXTidakValid
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeXLSXPart(files, sheetPath, &sheet); err != nil {
+		return nil, ErrXLSXTidakValid
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		rowNum := row.R
+		if rowNum == 0 {
+			rowNum = len(rows) + 1
+		}
+		if rowNum > xlsxMaxRows || rowNum <= len(rows) {
+			return nil, ErrXLSXTidakValid
+		}
+		for len(rows) < rowNum-1 {
+			rows = append(rows, []string{})
+		}
+
+		var cells []string
+		for _, c := range row.Cells {
+			col := len(cells)
+			if c.Ref != "" {
+				if col, err = xlsxColumnIndex(c.Ref); err != nil {
+					return nil, ErrXLSXTidakValid
+				}
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch c.Type {
+			case "s":
+				idx, err := strconv.Atoi(c.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, ErrXLSXTidakValid
+				}
+				cells[col] = shared.Items[idx].String()
+			case "inlineStr":
+				if c.Inline != nil {
+					cells[col] = c.Inline.String()
+				}
+			default:
+				cells[col] = c.Value
+			}
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+// decodeXLSXPart membaca satu bagian XML dari arsip XLSX
+func decodeXLSXPart(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return ErrXLSXTidakValid
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(io.LimitReader(rc, xlsxMaxPartSize)).Decode(v)
+}
+
+// xlsxColumnIndex mengubah referensi sel seperti "AB12" menjadi indeks kolom berbasis 0
+func xlsxColumnIndex(ref string) (int, error) {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		n++
+	}
+	if n == 0 || n > 3 {
+		return 0, fmt.Errorf("referensi sel %q tidak valid", ref)
+	}
+	return col - 1, nil
+}
+
+// xlsxColumnName mengubah indeks kolom berbasis 0 menjadi nama kolom seperti "AB"
+func xlsxColumnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+const xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs>` +
+	`</styleSheet>`
+
+// WriteXLSX menulis baris-baris sel ke file XLSX dengan satu sheet. Sel berisi bilangan bulat
+// ditulis sebagai angka, sel lain sebagai teks.
+func WriteXLSX(w io.Writer, sheetName string, rows [][]string) error {
+	zw := zip.NewWriter(w)
+
+	var workbook bytes.Buffer
+	workbook.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="`)
+	if err := xml.EscapeText(&workbook, []byte(sheetName)); err != nil {
+		return err
+	}
+	workbook.WriteString(`" sheetId="1" r:id="rId1"/></sheets></workbook>`)
+
+	var sheet bytes.Buffer
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, value := range row {
+			ref := xlsxColumnName(j) + strconv.Itoa(i+1)
+			if n, err := strconv.Atoi(value); err == nil && strconv.Itoa(n) == value {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+			fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&sheet, []byte(value)); err != nil {
+				return err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	parts := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", []byte(xlsxContentTypes)},
+		{"_rels/.rels", []byte(xlsxRootRels)},
+		{"xl/workbook.xml", workbook.Bytes()},
+		{"xl/_rels/workbook.xml.rels", []byte(xlsxWorkbookRels)},
+		{"xl/styles.xml", []byte(xlsxStyles)},
+		{"xl/worksheets/sheet1.xml", sheet.Bytes()},
+	}
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(part.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}